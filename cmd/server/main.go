@@ -18,7 +18,12 @@ import (
 
 	"github.com/AlexeiDevelop/subscriptions-api/internal/config"
 	"github.com/AlexeiDevelop/subscriptions-api/internal/handler"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/jobs"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/queue"
 	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/stream"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/trash"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/webhook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -46,6 +51,53 @@ func main() {
 
 	repo := storage.NewRepository(pool)
 	h := handler.New(repo, lg)
+	h.AdminToken = cfg.Admin.Token
+	if cfg.Admin.Token == "" {
+		lg.Warn("admin_token_empty", slog.String("hint", "admin routes are not protected, webhooks are disabled"))
+	}
+	if cfg.Rates.File != "" {
+		n, err := h.LoadRatesFile(ctx, cfg.Rates.File)
+		if err != nil {
+			lg.Error("rates load", slog.Any("err", err))
+			os.Exit(1)
+		}
+		lg.Info("rates_loaded", slog.Int("count", n), slog.String("file", cfg.Rates.File))
+	}
+
+	// фоновые задачи останавливаются вместе с сервером
+	bgCtx, stopBg := context.WithCancel(ctx)
+	defer stopBg()
+	disp := webhook.New(repo, lg, cfg.Webhooks.Timeout)
+	disp.Interval = cfg.Webhooks.Interval
+	disp.MaxAttempts = cfg.Webhooks.MaxAttempts
+	disp.EndingSoonDays = cfg.Webhooks.EndingSoonDays
+	if cfg.Webhooks.AllowPrivate {
+		h.AllowPrivateWebhooks = true
+		disp.Client = &http.Client{Timeout: cfg.Webhooks.Timeout}
+		lg.Warn("webhooks_allow_private", slog.String("hint", "webhooks may target internal network"))
+	}
+	go disp.Run(bgCtx)
+	h.Stream = stream.NewHub(pool, repo, lg)
+	go h.Stream.Run(bgCtx)
+	h.JobsDir = cfg.Jobs.Dir
+	worker := jobs.New(repo, lg, h.RunJob, cfg.Jobs.Dir)
+	worker.TTL = cfg.Jobs.TTL
+	worker.Interval = cfg.Jobs.Interval
+	go worker.Run(bgCtx)
+
+	// периодические задачи: по расписанию их ставит один инстанс-лидер, выполняет любой
+	q := queue.New(pool, repo, lg)
+	q.Workers = cfg.Queue.Workers
+	q.Interval = cfg.Queue.Interval
+	if cfg.Trash.Retention > 0 {
+		q.Schedule(trash.KindPurge, queue.Every(cfg.Trash.PurgeInterval), trash.New(repo, lg, cfg.Trash.Retention).Purge)
+	}
+	if cfg.Webhooks.EndingSoonDays > 0 {
+		q.Schedule(webhook.KindEndingSoon, queue.MustParseSpec("@hourly"), disp.EndingSoon)
+	}
+	q.Schedule(stream.KindPurge, queue.MustParseSpec("@hourly"), h.Stream.Purge)
+	q.Schedule(jobs.KindExpire, queue.Every(time.Minute), worker.Expire)
+	go q.Run(bgCtx)
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
@@ -64,6 +116,9 @@ func main() {
 		IdleTimeout:       60 * time.Second,
 	}
 
+	// при Shutdown сначала останавливаем фоновые задачи: хаб закроет SSE-потоки, иначе Shutdown ждал бы их до таймаута
+	srv.RegisterOnShutdown(stopBg)
+
 	go func() {
 		lg.Info("server_start", slog.Int("port", cfg.Server.Port))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -20,10 +21,48 @@ type Server struct {
 	Port int `mapstructure:"port"`
 }
 
+type Admin struct {
+	Token string `mapstructure:"token"`
+}
+
+type Rates struct {
+	File string `mapstructure:"file"` // JSON с курсами, загружается при старте
+}
+
+type Webhooks struct {
+	Interval       time.Duration `mapstructure:"interval"`         // опрос outbox и очереди доставок
+	Timeout        time.Duration `mapstructure:"timeout"`          // таймаут одного запроса к вебхуку
+	MaxAttempts    int           `mapstructure:"max_attempts"`     // после стольких неудач доставка — dead
+	EndingSoonDays int           `mapstructure:"ending_soon_days"` // 0 — без subscription.ending_soon
+	AllowPrivate   bool          `mapstructure:"allow_private"`    // разрешить вебхуки на localhost и частные сети (разработка)
+}
+
+type Trash struct {
+	Retention     time.Duration `mapstructure:"retention"`      // сколько удалённая подписка хранится в корзине, 0 — вечно
+	PurgeInterval time.Duration `mapstructure:"purge_interval"` // как часто очищать корзину
+}
+
+type Jobs struct {
+	Dir      string        `mapstructure:"dir"`      // каталог файлов выгрузок и отчётов
+	TTL      time.Duration `mapstructure:"ttl"`      // сколько хранится готовый файл
+	Interval time.Duration `mapstructure:"interval"` // как часто проверять очередь задач
+}
+
+type Queue struct {
+	Workers  int           `mapstructure:"workers"`  // задач одновременно на инстанс
+	Interval time.Duration `mapstructure:"interval"` // как часто проверять очередь и расписание
+}
+
 type Config struct {
 	Env    string `mapstructure:"env"`
 	Server Server `mapstructure:"server"`
 	DB     DB     `mapstructure:"db"`
+	Admin  Admin  `mapstructure:"admin"`
+	Rates  Rates  `mapstructure:"rates"`
+	Webhooks Webhooks `mapstructure:"webhooks"`
+	Trash    Trash    `mapstructure:"trash"`
+	Jobs     Jobs     `mapstructure:"jobs"`
+	Queue    Queue    `mapstructure:"queue"`
 }
 
 func Load() (*Config, error) {
@@ -38,6 +77,20 @@ func Load() (*Config, error) {
 	v.SetDefault("db.password", "postgres")
 	v.SetDefault("db.name", "subscriptions")
 	v.SetDefault("db.sslmode", "disable")
+	v.SetDefault("admin.token", "")
+	v.SetDefault("rates.file", "")
+	v.SetDefault("webhooks.interval", "5s")
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.max_attempts", 8)
+	v.SetDefault("webhooks.ending_soon_days", 7)
+	v.SetDefault("webhooks.allow_private", false)
+	v.SetDefault("trash.retention", "720h")
+	v.SetDefault("trash.purge_interval", "1h")
+	v.SetDefault("jobs.dir", "data/jobs")
+	v.SetDefault("jobs.ttl", "24h")
+	v.SetDefault("jobs.interval", "2s")
+	v.SetDefault("queue.workers", 4)
+	v.SetDefault("queue.interval", "1s")
 
 	// YAML
 	v.SetConfigName("config")
@@ -59,6 +112,20 @@ func Load() (*Config, error) {
 		"db.password": "APP_DB_PASSWORD",
 		"db.name": "APP_DB_NAME",
 		"db.sslmode": "APP_DB_SSLMODE",
+		"admin.token": "APP_ADMIN_TOKEN",
+		"rates.file": "APP_RATES_FILE",
+		"webhooks.interval": "APP_WEBHOOKS_INTERVAL",
+		"webhooks.timeout": "APP_WEBHOOKS_TIMEOUT",
+		"webhooks.max_attempts": "APP_WEBHOOKS_MAX_ATTEMPTS",
+		"webhooks.ending_soon_days": "APP_WEBHOOKS_ENDING_SOON_DAYS",
+		"webhooks.allow_private": "APP_WEBHOOKS_ALLOW_PRIVATE",
+		"trash.retention": "APP_TRASH_RETENTION",
+		"trash.purge_interval": "APP_TRASH_PURGE_INTERVAL",
+		"jobs.dir": "APP_JOBS_DIR",
+		"jobs.ttl": "APP_JOBS_TTL",
+		"jobs.interval": "APP_JOBS_INTERVAL",
+		"queue.workers": "APP_QUEUE_WORKERS",
+		"queue.interval": "APP_QUEUE_INTERVAL",
 	}
 	for k, e := range bindEnv {
 		_ = v.BindEnv(k, e)
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+const maxActorLen = 100
+
+// auditMeta: кладёт в context автора изменения (заголовок X-Actor) и X-Request-Id для журнала аудита
+func auditMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get("X-Actor"))
+		if actor == "" {
+			actor = "anonymous"
+		}
+		if len(actor) > maxActorLen {
+			actor = actor[:maxActorLen]
+		}
+		ctx := storage.WithAuditMeta(r.Context(), storage.AuditMeta{
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GET /subscriptions/{id}/history?limit=&before_id=
+// Subscription history
+// @Summary      Subscription history
+// @Description  Все изменения подписки (в т.ч. удаление) с состоянием до и после, автором и request id, новые сначала
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id         path      string  true   "UUID подписки"
+// @Param        limit      query     int     false  "Количество (default 50, max 200)"
+// @Param        before_id  query     int     false  "Следующая страница: next_before предыдущего ответа"
+// @Success      200        {object}  model.AuditPage
+// @Failure      400        {object}  map[string]string  "Bad request"
+// @Failure      500        {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/history [get]
+func (h *Handler) history(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	f, err := parseAuditPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.SubscriptionID = &id
+	h.writeAudit(w, r, f)
+}
+
+// GET /audit
+// Audit log
+// @Summary      Audit log
+// @Description  Журнал изменений подписок с фильтрами, новые сначала
+// @Tags         admin
+// @Produce      json
+// @Param        subscription_id  query     string  false  "UUID подписки"
+// @Param        user_id          query     string  false  "UUID пользователя-владельца подписки"
+// @Param        actor            query     string  false  "Автор изменения (X-Actor)"
+// @Param        action           query     string  false  "created | updated | deleted | restored"
+// @Param        request_id       query     string  false  "X-Request-Id запроса"
+// @Param        from             query     string  false  "С даты (YYYY-MM-DD или MM-YYYY)"
+// @Param        to               query     string  false  "По дату включительно (YYYY-MM-DD или MM-YYYY)"
+// @Param        limit            query     int     false  "Количество (default 50, max 200)"
+// @Param        before_id        query     int     false  "Следующая страница: next_before предыдущего ответа"
+// @Success      200              {object}  model.AuditPage
+// @Failure      400              {object}  map[string]string  "Bad request"
+// @Failure      401              {object}  map[string]string  "Unauthorized"
+// @Failure      500              {object}  map[string]string  "Internal error"
+// @Router       /audit [get]
+func (h *Handler) audit(w http.ResponseWriter, r *http.Request) {
+	f, err := parseAuditPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	if s := strings.TrimSpace(q.Get("subscription_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad subscription_id")
+			return
+		}
+		f.SubscriptionID = &u
+	}
+	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad user_id")
+			return
+		}
+		f.UserID = &u
+	}
+	if s := strings.TrimSpace(q.Get("actor")); s != "" {
+		f.Actor = &s
+	}
+	if s := strings.TrimSpace(q.Get("request_id")); s != "" {
+		f.RequestID = &s
+	}
+	if s := strings.TrimSpace(q.Get("action")); s != "" {
+		if s != "created" && s != "updated" && s != "deleted" && s != "restored" {
+			writeError(w, http.StatusBadRequest, "bad action, use created|updated|deleted|restored")
+			return
+		}
+		f.Action = &s
+	}
+	if f.From, err = queryDate(q, "from", false); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := queryDate(q, "to", true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if to != nil {
+		next := to.AddDate(0, 0, 1)
+		f.To = &next
+	}
+	h.writeAudit(w, r, f)
+}
+
+func (h *Handler) writeAudit(w http.ResponseWriter, r *http.Request, f storage.AuditFilter) {
+	items, next, err := h.Repo.ListAudit(r.Context(), f)
+	if err != nil {
+		h.Log.Error("audit", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, model.AuditPage{Items: items, NextBefore: next})
+}
+
+func parseAuditPage(r *http.Request) (storage.AuditFilter, error) {
+	q := r.URL.Query()
+	f := storage.AuditFilter{Limit: 50}
+	if v, err := queryInt(q, "limit"); err != nil || (v != nil && (*v < 1 || *v > 200)) {
+		return f, errors.New("bad limit, use 1-200")
+	} else if v != nil {
+		f.Limit = *v
+	}
+	if s := strings.TrimSpace(q.Get("before_id")); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return f, errors.New("bad before_id")
+		}
+		f.BeforeID = &v
+	}
+	return f, nil
+}
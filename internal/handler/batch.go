@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// maxBatch: операций в одном пакетном запросе
+const maxBatch = 1000
+
+// errBatchRollback: atomic-пакет откатывается из-за ошибки в одной из операций
+var errBatchRollback = errors.New("batch rolled back")
+
+// batchItem: операция пакета после разбора
+type batchItem struct {
+	id      uuid.UUID
+	sub     *model.Subscription
+	ifMatch []int
+}
+
+// POST /subscriptions/batch
+// Batch create, update and delete
+// @Summary      Batch subscriptions
+// @Description  Пакет операций create/update/delete в одной транзакции. mode=atomic (по умолчанию) — всё или ничего, mode=best_effort — ошибочные операции пропускаются, остальные сохраняются. Данные проверяются так же, как в POST/PUT /subscriptions; в results для каждой операции — HTTP-код, который дал бы одиночный запрос. Не больше 1000 операций
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      model.BatchRequest  true  "Операции"
+// @Success      200      {object}  model.BatchResponse "Пакет выполнен (в best_effort — возможно, частично)"
+// @Failure      400      {object}  map[string]string   "Bad request"
+// @Failure      422      {object}  model.BatchResponse "atomic-пакет откатился из-за ошибки в операции"
+// @Failure      500      {object}  map[string]string   "Internal error"
+// @Router       /subscriptions/batch [post]
+func (h *Handler) batch(w http.ResponseWriter, r *http.Request) {
+	var req model.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = model.BatchAtomic
+	}
+	if req.Mode != model.BatchAtomic && req.Mode != model.BatchBestEffort {
+		writeError(w, http.StatusBadRequest, "bad mode, use atomic|best_effort")
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatch {
+		writeError(w, http.StatusBadRequest, "operations: from 1 to "+strconv.Itoa(maxBatch))
+		return
+	}
+	atomic := req.Mode == model.BatchAtomic
+	ctx := r.Context()
+
+	// сначала разбираем все операции: в atomic-режиме невалидный пакет даже не открывает транзакцию
+	items := make([]batchItem, len(req.Operations))
+	results := make([]model.BatchResult, len(req.Operations))
+	invalid := false
+	for i, op := range req.Operations {
+		results[i] = model.BatchResult{Index: i, Op: op.Op}
+		it, status, err := h.parseBatchOp(r, op)
+		if err != nil {
+			results[i].Status, results[i].Error = status, err.Error()
+			invalid = true
+			continue
+		}
+		if it.id != uuid.Nil {
+			id := it.id
+			results[i].ID = &id
+		}
+		items[i] = it
+	}
+
+	touched := map[uuid.UUID]uuid.UUID{} // пользователь -> последняя его созданная/изменённая подписка, для проверки бюджетов
+	err := errBatchRollback
+	if !atomic || !invalid {
+		err = h.Repo.InTx(ctx, func(tx *storage.Repository) error {
+			for i, op := range req.Operations {
+				if results[i].Status != 0 {
+					continue
+				}
+				status, err := runBatchOp(ctx, tx, op.Op, &items[i])
+				results[i].Status = status
+				if err != nil {
+					if status == http.StatusInternalServerError {
+						h.Log.Error("batch", slog.Int("index", i), slog.Any("err", err))
+						err = errors.New("db error")
+					}
+					results[i].Error = err.Error()
+					if atomic {
+						return errBatchRollback
+					}
+					continue
+				}
+				if op.Op == "create" {
+					id := items[i].sub.ID
+					results[i].ID = &id
+				}
+				if items[i].sub != nil {
+					touched[items[i].sub.UserID] = items[i].sub.ID
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil && !errors.Is(err, errBatchRollback) {
+		h.Log.Error("batch", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	resp := model.BatchResponse{Committed: err == nil, Results: results}
+	for i := range results {
+		switch {
+		case results[i].Error != "":
+			resp.Failed++
+		case !resp.Committed:
+			results[i].Status = http.StatusFailedDependency
+			if results[i].Op == "create" {
+				results[i].ID = nil
+			}
+			resp.Failed++
+		default:
+			resp.Succeeded++
+		}
+	}
+	if !resp.Committed {
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	for uid, subID := range touched {
+		h.checkBudgets(ctx, uid, subID)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseBatchOp: проверка операции теми же правилами, что у одиночных запросов; при ошибке — и HTTP-код
+func (h *Handler) parseBatchOp(r *http.Request, op model.BatchOperation) (batchItem, int, error) {
+	var it batchItem
+	switch op.Op {
+	case "create", "update", "delete":
+	default:
+		return it, http.StatusBadRequest, errors.New("bad op, use create|update|delete")
+	}
+	if op.Op != "create" {
+		id, err := uuid.Parse(op.ID)
+		if err != nil {
+			return it, http.StatusBadRequest, errors.New("bad id")
+		}
+		it.id = id
+		if op.Version != nil {
+			it.ifMatch = []int{*op.Version}
+		}
+	}
+	if op.Op == "delete" {
+		return it, 0, nil
+	}
+	if op.Data == nil {
+		return it, http.StatusBadRequest, errors.New("missing data")
+	}
+	s, err := parsePayload(*op.Data)
+	if err != nil {
+		return it, http.StatusBadRequest, err
+	}
+	if err := h.applyCatalog(r.Context(), *op.Data, s); err != nil {
+		h.Log.Error("resolve service", slog.Any("err", err))
+		return it, http.StatusInternalServerError, errors.New("db error")
+	}
+	it.sub = s
+	return it, 0, nil
+}
+
+// runBatchOp: операция в транзакции пакета; методы репозитория сами открывают savepoint,
+// поэтому ошибка откатывает только эту операцию. Возвращает HTTP-код результата.
+func runBatchOp(ctx context.Context, tx *storage.Repository, op string, it *batchItem) (int, error) {
+	var (
+		ok  bool
+		err error
+	)
+	switch op {
+	case "create":
+		_, err = tx.Create(ctx, it.sub)
+		ok = true
+	case "update":
+		ok, err = tx.Update(ctx, it.id, it.sub, it.ifMatch)
+		it.sub.ID = it.id
+	case "delete":
+		ok, err = tx.Delete(ctx, it.id, it.ifMatch)
+	}
+	switch {
+	case errors.Is(err, storage.ErrPrecondition):
+		return http.StatusPreconditionFailed, errors.New("version mismatch")
+	case err != nil:
+		return http.StatusInternalServerError, err
+	case !ok:
+		return http.StatusNotFound, errors.New("not found")
+	case op == "create":
+		return http.StatusCreated, nil
+	case op == "delete":
+		return http.StatusNoContent, nil
+	}
+	return http.StatusOK, nil
+}
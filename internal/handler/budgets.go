@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// POST /budgets
+// Create budget
+// @Summary      Create budget
+// @Description  Месячный бюджет пользователя: общий (scope=total), на сервис (service) или на категорию каталога (category)
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      model.BudgetPayload  true  "Бюджет"
+// @Success      201      {object}  model.Budget
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      409      {object}  map[string]string  "Такой бюджет уже есть"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /budgets [post]
+func (h *Handler) createBudget(w http.ResponseWriter, r *http.Request) {
+	var p model.BudgetPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	b, err := parseBudgetPayload(p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.Repo.CreateBudget(r.Context(), b); err != nil {
+		h.budgetError(w, "create budget", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// GET /budgets?user_id=
+// List budgets
+// @Summary      List budgets
+// @Description  Бюджеты пользователя (или всех)
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query     string  false  "UUID пользователя"
+// @Success      200      {array}   model.Budget
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /budgets [get]
+func (h *Handler) listBudgets(w http.ResponseWriter, r *http.Request) {
+	uid, err := queryUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	items, err := h.Repo.ListBudgets(r.Context(), uid)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GET /budgets/{id}
+// Get budget
+// @Summary      Get budget
+// @Description  Бюджет по идентификатору
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "UUID бюджета"
+// @Success      200  {object}  model.Budget
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /budgets/{id} [get]
+func (h *Handler) getBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	b, err := h.Repo.GetBudget(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if b == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// PUT /budgets/{id}
+// Update budget
+// @Summary      Update budget
+// @Description  Обновить бюджет
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string               true  "UUID бюджета"
+// @Param        payload  body      model.BudgetPayload  true  "Бюджет"
+// @Success      200      {object}  model.Budget
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      409      {object}  map[string]string  "Такой бюджет уже есть"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /budgets/{id} [put]
+func (h *Handler) updateBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	var p model.BudgetPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	b, err := parseBudgetPayload(p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ok, err := h.Repo.UpdateBudget(r.Context(), id, b)
+	if err != nil {
+		h.budgetError(w, "update budget", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// DELETE /budgets/{id}
+// Delete budget
+// @Summary      Delete budget
+// @Description  Удалить бюджет вместе с историей превышений
+// @Tags         budgets
+// @Param        id   path      string  true  "UUID бюджета"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /budgets/{id} [delete]
+func (h *Handler) deleteBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	ok, err := h.Repo.DeleteBudget(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /budgets/status?user_id=
+// Budget status
+// @Summary      Budget status
+// @Description  Расходы текущего месяца по каждому бюджету пользователя: spent — с начала месяца по сегодня, forecast — за весь месяц с учётом предстоящих списаний
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query     string  true  "UUID пользователя"
+// @Success      200      {array}   model.BudgetStatus
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      422      {object}  map[string]string  "Нет курса валюты"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /budgets/status [get]
+func (h *Handler) budgetStatus(w http.ResponseWriter, r *http.Request) {
+	uid, err := queryUserID(r)
+	if err != nil || uid == nil {
+		writeError(w, http.StatusBadRequest, "user_id required")
+		return
+	}
+	items, err := h.budgetStatuses(r.Context(), *uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRate) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		h.Log.Error("budget status", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GET /budgets/breaches?user_id=&limit=
+// Budget breaches
+// @Summary      Budget breaches
+// @Description  События превышения бюджетов, новые сначала. Событие создаётся, когда создание или изменение подписки выводит прогноз месяца за лимит, — не больше одного на бюджет в месяц
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query     string  false  "UUID пользователя"
+// @Param        limit    query     int     false  "Количество (default 50, max 200)"
+// @Success      200      {array}   model.BudgetBreach
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /budgets/breaches [get]
+func (h *Handler) listBreaches(w http.ResponseWriter, r *http.Request) {
+	uid, err := queryUserID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 50
+	if v, err := queryInt(r.URL.Query(), "limit"); err == nil && v != nil && *v > 0 && *v <= 200 {
+		limit = *v
+	}
+	items, err := h.Repo.ListBreaches(r.Context(), uid, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// budgetStatuses: состояние всех бюджетов пользователя на текущий месяц
+func (h *Handler) budgetStatuses(ctx context.Context, uid uuid.UUID) ([]model.BudgetStatus, error) {
+	budgets, err := h.Repo.ListBudgets(ctx, &uid)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, 1-today.Day())
+	to := from.AddDate(0, 1, -1)
+
+	res := make([]model.BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		f := storage.SummaryFilter{
+			UserID:   &b.UserID,
+			Currency: b.Currency,
+			Mode:     storage.SummaryMode(b.Mode),
+		}
+		switch b.Scope {
+		case model.BudgetService:
+			f.ServiceName, f.ServiceMatch = b.Target, storage.MatchCI
+		case model.BudgetCategory:
+			f.Category = b.Target
+		}
+		f.From, f.To = from, today
+		spent, _, err := h.Repo.Summary(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		f.To = to
+		forecast, _, err := h.Repo.Summary(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, model.BudgetStatus{
+			Budget:    b,
+			Month:     from.Format("2006-01"),
+			Spent:     spent,
+			Forecast:  forecast,
+			Remaining: round2(float64(b.Amount) - forecast),
+			Over:      forecast > float64(b.Amount),
+		})
+	}
+	return res, nil
+}
+
+// checkBudgets: после создания/изменения подписки записывает превышения бюджетов пользователя.
+// Ошибки только логируются — на ответ клиенту проверка не влияет.
+func (h *Handler) checkBudgets(ctx context.Context, uid uuid.UUID, subID uuid.UUID) {
+	items, err := h.budgetStatuses(ctx, uid)
+	if err != nil {
+		h.Log.Warn("check budgets", slog.String("user_id", uid.String()), slog.Any("err", err))
+		return
+	}
+	month := time.Now().UTC()
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, st := range items {
+		if !st.Over {
+			continue
+		}
+		br := &model.BudgetBreach{
+			BudgetID:       st.Budget.ID,
+			UserID:         uid,
+			Spent:          st.Forecast,
+			Amount:         st.Budget.Amount,
+			Currency:       st.Budget.Currency,
+			SubscriptionID: &subID,
+		}
+		added, err := h.Repo.AddBreach(ctx, br, month)
+		if err != nil {
+			h.Log.Warn("add budget breach", slog.Any("err", err))
+			continue
+		}
+		if added {
+			h.Log.Info("budget breached",
+				slog.String("user_id", uid.String()),
+				slog.String("budget_id", st.Budget.ID.String()),
+				slog.Float64("forecast", st.Forecast),
+				slog.Int("amount", st.Budget.Amount))
+		}
+	}
+}
+
+func (h *Handler) budgetError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, storage.ErrConflict) {
+		writeError(w, http.StatusConflict, "budget with this scope and target already exists")
+		return
+	}
+	h.Log.Error(op, slog.Any("err", err))
+	writeError(w, http.StatusInternalServerError, "db error")
+}
+
+func queryUserID(r *http.Request) (*uuid.UUID, error) {
+	s := strings.TrimSpace(r.URL.Query().Get("user_id"))
+	if s == "" {
+		return nil, nil
+	}
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return nil, errors.New("bad user_id")
+	}
+	return &u, nil
+}
+
+func parseBudgetPayload(p model.BudgetPayload) (*model.Budget, error) {
+	uid, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return nil, errors.New("bad user_id")
+	}
+	if p.Amount <= 0 {
+		return nil, errors.New("bad amount")
+	}
+	b := &model.Budget{
+		UserID:   uid,
+		Scope:    model.BudgetTotal,
+		Amount:   p.Amount,
+		Currency: model.BaseCurrency,
+		Mode:     string(storage.SummaryCharges),
+	}
+	if p.Scope != "" {
+		b.Scope = model.BudgetScope(p.Scope)
+		if !b.Scope.Valid() {
+			return nil, errors.New("bad scope, use total|service|category")
+		}
+	}
+	if p.Target != nil {
+		if t := strings.TrimSpace(*p.Target); t != "" {
+			b.Target = &t
+		}
+	}
+	if (b.Scope == model.BudgetTotal) != (b.Target == nil) {
+		return nil, errors.New("target required for service/category scope and not allowed for total")
+	}
+	if p.Currency != "" {
+		c, err := parseCurrency(p.Currency)
+		if err != nil {
+			return nil, errors.New("bad currency, use ISO 4217 code")
+		}
+		b.Currency = c
+	}
+	if p.Mode != "" {
+		if m := storage.SummaryMode(p.Mode); m != storage.SummaryCharges && m != storage.SummaryAmortized {
+			return nil, errors.New("bad mode, use charges|amortized")
+		}
+		b.Mode = p.Mode
+	}
+	return b, nil
+}
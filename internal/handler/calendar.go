@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// POST /users/{user_id}/calendar-feed
+// Issue calendar feed
+// @Summary      Issue calendar feed
+// @Description  Выпустить секретную ссылку на iCalendar-ленту списаний пользователя; предыдущая ссылка перестаёт работать. Токен хранится только в виде хэша и показывается один раз
+// @Tags         calendar
+// @Produce      json
+// @Param        user_id  path      string  true  "UUID пользователя"
+// @Success      201      {object}  model.CalendarFeed
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /users/{user_id}/calendar-feed [post]
+func (h *Handler) issueCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad user_id")
+		return
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := h.Repo.SetCalendarToken(r.Context(), uid, hashToken(token)); err != nil {
+		h.Log.Error("calendar token", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	writeJSON(w, http.StatusCreated, model.CalendarFeed{
+		UserID: uid,
+		Token:  token,
+		URL:    scheme + "://" + r.Host + "/calendar/" + token + ".ics",
+	})
+}
+
+// DELETE /users/{user_id}/calendar-feed
+// Revoke calendar feed
+// @Summary      Revoke calendar feed
+// @Description  Отозвать ссылку на iCalendar-ленту пользователя
+// @Tags         calendar
+// @Param        user_id  path      string  true  "UUID пользователя"
+// @Success      204      {string}  string  "No Content"
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /users/{user_id}/calendar-feed [delete]
+func (h *Handler) revokeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad user_id")
+		return
+	}
+	ok, err := h.Repo.DeleteCalendarToken(r.Context(), uid)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /calendar/{token}.ics
+// Calendar feed
+// @Summary      Calendar feed
+// @Description  iCalendar (RFC 5545) с повторяющимися событиями списаний по каждой подписке пользователя от start_date до end_date. Лента строится при каждом запросе, поэтому изменения подписок видны при следующем обновлении календаря
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        token  path      string  true  "Токен ленты"
+// @Success      200    {string}  string  "VCALENDAR"
+// @Failure      404    {object}  map[string]string  "Not found"
+// @Failure      500    {object}  map[string]string  "Internal error"
+// @Router       /calendar/{token}.ics [get]
+func (h *Handler) calendarFeed(w http.ResponseWriter, r *http.Request) {
+	uid, err := h.Repo.CalendarUser(r.Context(), hashToken(chi.URLParam(r, "token")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if uid == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	series, err := h.Repo.CalendarSeries(r.Context(), *uid)
+	if err != nil {
+		h.Log.Error("calendar feed", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	var b icsBuilder
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//subscriptions-api//renewals//RU")
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:PUBLISH")
+	b.line("X-WR-CALNAME:" + icsText("Списания по подпискам"))
+	b.line("REFRESH-INTERVAL;VALUE=DURATION:PT12H")
+	b.line("X-PUBLISHED-TTL:PT12H")
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, s := range series {
+		title := fmt.Sprintf("%s — %d %s", s.ServiceName, s.Price, s.Currency)
+		b.line("BEGIN:VEVENT")
+		b.line("UID:" + s.SubscriptionID.String() + "-" + s.ValidFrom.Format("20060102") + "@subscriptions-api")
+		b.line("DTSTAMP:" + stamp)
+		b.line("LAST-MODIFIED:" + s.UpdatedAt.UTC().Format("20060102T150405Z"))
+		b.line("DTSTART;VALUE=DATE:" + s.First.Format("20060102"))
+		b.line("DTEND;VALUE=DATE:" + s.First.AddDate(0, 0, 1).Format("20060102"))
+		if s.Last == nil || s.Last.After(s.First) {
+			b.line("RRULE:" + rrule(s))
+		}
+		b.line("SUMMARY:" + icsText(title))
+		b.line("DESCRIPTION:" + icsText("Списание по подписке "+title))
+		b.line("TRANSP:TRANSPARENT")
+		b.line("END:VEVENT")
+	}
+	b.line("END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// rrule: правило повторения, совпадающее с charge_dates.
+// Postgres при прибавлении месяцев прижимает 29–31 число к концу короткого месяца,
+// в RRULE то же даёт BYMONTHDAY=28..D;BYSETPOS=-1 (иначе такие месяцы пропускаются).
+func rrule(s model.CalendarSeries) string {
+	freq, n := "MONTHLY", s.BillingInterval
+	switch s.BillingPeriod {
+	case model.BillingWeekly:
+		freq = "WEEKLY"
+	case model.BillingQuarterly:
+		n *= 3
+	case model.BillingYearly:
+		freq = "YEARLY"
+	}
+	rule := "FREQ=" + freq + ";INTERVAL=" + strconv.Itoa(n)
+	if day := s.StartDate.Day(); freq != "WEEKLY" && day > 28 {
+		if freq == "YEARLY" {
+			rule += ";BYMONTH=" + strconv.Itoa(int(s.StartDate.Month()))
+		}
+		days := make([]string, 0, day-27)
+		for d := 28; d <= day; d++ {
+			days = append(days, strconv.Itoa(d))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+	if s.Last != nil {
+		rule += ";UNTIL=" + s.Last.Format("20060102")
+	}
+	return rule
+}
+
+// icsText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsBuilder: строки с CRLF, длиннее 75 октетов переносятся (RFC 5545, 3.1) не разрывая UTF-8
+type icsBuilder struct {
+	strings.Builder
+}
+
+func (b *icsBuilder) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // продолжение начинается с пробела
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
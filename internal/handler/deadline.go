@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// writeTimeout: сколько ждать клиента на одну запись ответа
+const writeTimeout = 30 * time.Second
+
+// deadlineWriter: ответ, который живёт дольше WriteTimeout сервера (поток событий, выгрузка, файл задачи).
+// Дедлайн записи сдвигается на writeTimeout перед каждой записью: долгий ответ не обрывается,
+// а зависший клиент не держит соединение дольше writeTimeout
+type deadlineWriter struct {
+	http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newDeadlineWriter сразу ставит первый дедлайн; ошибка — соединение не поддерживает дедлайны,
+// тогда ответ ограничен WriteTimeout сервера
+func newDeadlineWriter(w http.ResponseWriter) (deadlineWriter, error) {
+	dw := deadlineWriter{ResponseWriter: w, rc: http.NewResponseController(w)}
+	return dw, dw.extend()
+}
+
+func (dw deadlineWriter) extend() error {
+	return dw.rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+}
+
+func (dw deadlineWriter) Write(p []byte) (int, error) {
+	dw.extend()
+	return dw.ResponseWriter.Write(p)
+}
+
+func (dw deadlineWriter) Flush() error {
+	return dw.rc.Flush()
+}
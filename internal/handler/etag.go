@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+// etag: сильный ETag подписки — её версия
+func etag(s *model.Subscription) string {
+	return `"` + strconv.Itoa(s.Version) + `"`
+}
+
+// parseIfMatch: версии из If-Match. nil — заголовка нет или он «*» (подходит любая версия).
+// Слабые ETag (W/...) при сильном сравнении не совпадают ни с чем, поэтому пропускаются:
+// If-Match только из них даёт пустой (не nil) список, т.е. всегда 412.
+func parseIfMatch(r *http.Request) []int {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return nil
+	}
+	versions := []int{}
+	for _, t := range strings.Split(h, ",") {
+		t = strings.TrimSpace(t)
+		if strings.HasPrefix(t, "W/") || len(t) < 2 || t[0] != '"' || t[len(t)-1] != '"' {
+			continue
+		}
+		if v, err := strconv.Atoi(t[1 : len(t)-1]); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// notModified: If-None-Match совпадает с текущим ETag (слабое сравнение)
+func notModified(r *http.Request, tag string) bool {
+	h := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if h == "" {
+		return false
+	}
+	if h == "*" {
+		return true
+	}
+	for _, t := range strings.Split(h, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+// exportFormats: формат выгрузки -> Content-Type
+var exportFormats = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"json":   "application/json",
+}
+
+// exportCSVHeader: колонки CSV-выгрузки; совпадают с полями импорта, поэтому файл можно загрузить обратно
+var exportCSVHeader = []string{"id", "service_name", "service_id", "price", "currency", "billing_period", "billing_interval",
+	"user_id", "start_date", "end_date", "tags", "created_at", "updated_at", "version"}
+
+// GET /subscriptions/export
+// Export subscriptions
+// @Summary      Export subscriptions
+// @Description  Выгрузка всех подписок под фильтрами (как у GET /subscriptions, без пагинации) потоком в CSV, NDJSON или JSON-массиве. Формат — параметр format или заголовок Accept (text/csv, application/x-ndjson, application/json), по умолчанию JSON. Ответ не ограничен WriteTimeout сервера (но клиент, не читающий ответ 30 с, отключается); если выгрузка прервалась на середине, соединение закрывается без завершения ответа
+// @Tags         subscriptions
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format         query     string    false  "csv | ndjson | json"
+// @Param        user_id        query     []string  false  "UUID пользователей (повтор параметра или через запятую)"  collectionFormat(multi)
+// @Param        service_name   query     []string  false  "Названия сервисов (повтор параметра)"  collectionFormat(multi)
+// @Param        service_match  query     string    false  "Сравнение service_name: exact (default), ci, prefix, fuzzy"
+// @Param        category       query     []string  false  "Категории каталога сервисов (повтор параметра)"  collectionFormat(multi)
+// @Param        tag            query     []string  false  "Есть любой из тегов (повтор параметра)"  collectionFormat(multi)
+// @Param        active_at      query     string    false  "Активна в этот день (YYYY-MM-DD или MM-YYYY)"
+// @Param        sort           query     string    false  "Поле[:asc|desc], как у GET /subscriptions (default created_at:desc)"
+// @Success      200            {array}   model.Subscription
+// @Failure      400            {object}  map[string]string  "Bad request"
+// @Failure      406            {object}  map[string]string  "Unsupported format"
+// @Failure      500            {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/export [get]
+func (h *Handler) export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f, err := parseListFilter(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, ok := exportFormat(r)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, "bad format, use csv|ndjson|json")
+		return
+	}
+
+	dw, err := newDeadlineWriter(w)
+	if err != nil {
+		h.Log.Warn("export write deadline", slog.Any("err", err))
+	}
+	bw := bufio.NewWriterSize(dw, 32<<10)
+	ew := newExportWriter(format, bw)
+	started, n := false, 0
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", exportFormats[format])
+		w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+format+`"`)
+		w.WriteHeader(http.StatusOK)
+		return ew.begin()
+	}
+
+	err = h.Repo.Export(r.Context(), f, func(s *model.Subscription) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := ew.write(s); err != nil {
+			return err
+		}
+		// отдаём клиенту порциями, а не всё в конце
+		if n++; n%500 == 0 {
+			if err := ew.flush(); err != nil {
+				return err
+			}
+			return dw.Flush()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		if err = ew.end(); err == nil {
+			err = ew.flush()
+		}
+	}
+	if err != nil {
+		if !started {
+			h.Log.Error("export", slog.Any("err", err))
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		// заголовки уже ушли: обрываем соединение, чтобы клиент не принял неполный файл за целый
+		h.Log.Error("export aborted", slog.Int("rows", n), slog.Any("err", err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFormat: format из query, иначе первый подходящий тип из Accept; по умолчанию json
+func exportFormat(r *http.Request) (string, bool) {
+	if f := strings.TrimSpace(r.URL.Query().Get("format")); f != "" {
+		_, ok := exportFormats[f]
+		return f, ok
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, _ := mime.ParseMediaType(strings.TrimSpace(part))
+		switch mt {
+		case "text/csv":
+			return "csv", true
+		case "application/x-ndjson", "application/ndjson":
+			return "ndjson", true
+		case "application/json":
+			return "json", true
+		}
+	}
+	return "json", true
+}
+
+// exportWriter пишет подписки в выбранном формате
+type exportWriter struct {
+	format string
+	w      *bufio.Writer
+	csv    *csv.Writer
+	first  bool
+}
+
+func newExportWriter(format string, w *bufio.Writer) *exportWriter {
+	ew := &exportWriter{format: format, w: w, first: true}
+	if format == "csv" {
+		ew.csv = csv.NewWriter(w)
+	}
+	return ew
+}
+
+func (ew *exportWriter) begin() error {
+	switch ew.format {
+	case "csv":
+		return ew.csv.Write(exportCSVHeader)
+	case "json":
+		_, err := ew.w.WriteString("[")
+		return err
+	}
+	return nil
+}
+
+func (ew *exportWriter) write(s *model.Subscription) error {
+	if ew.format == "csv" {
+		return ew.csv.Write(exportCSVRow(s))
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if ew.format == "json" && !ew.first {
+		ew.w.WriteByte(',')
+	}
+	ew.first = false
+	ew.w.Write(b)
+	if ew.format == "ndjson" {
+		ew.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (ew *exportWriter) end() error {
+	if ew.format == "json" {
+		_, err := ew.w.WriteString("]\n")
+		return err
+	}
+	return nil
+}
+
+// flush: всё записанное — в ResponseWriter (у csv.Writer свой буфер)
+func (ew *exportWriter) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return ew.w.Flush()
+}
+
+func exportCSVRow(s *model.Subscription) []string {
+	serviceID, end := "", ""
+	if s.ServiceID != nil {
+		serviceID = s.ServiceID.String()
+	}
+	if s.EndDate != nil {
+		end = s.EndDate.Format(time.DateOnly)
+	}
+	return []string{
+		s.ID.String(), s.ServiceName, serviceID, strconv.Itoa(s.Price), s.Currency, string(s.BillingPeriod),
+		strconv.Itoa(s.BillingInterval), s.UserID.String(), s.StartDate.Format(time.DateOnly), end,
+		strings.Join(s.Tags, ","), s.CreatedAt.Format(time.RFC3339), s.UpdatedAt.Format(time.RFC3339), strconv.Itoa(s.Version),
+	}
+}
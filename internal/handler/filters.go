@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// parseListFilter: фильтры и сортировка списка из query (без пагинации)
+func parseListFilter(q url.Values) (storage.ListFilter, error) {
+	var f storage.ListFilter
+
+	// user_id можно повторять и перечислять через запятую
+	for _, v := range q["user_id"] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			u, err := uuid.Parse(s)
+			if err != nil {
+				return f, errors.New("bad user_id")
+			}
+			f.UserIDs = append(f.UserIDs, u)
+		}
+	}
+	// service_name только повторением: запятая может быть частью названия
+	for _, v := range q["service_name"] {
+		if s := strings.TrimSpace(v); s != "" {
+			f.ServiceNames = append(f.ServiceNames, s)
+		}
+	}
+
+	for _, v := range q["category"] {
+		if s := strings.TrimSpace(v); s != "" {
+			f.Categories = append(f.Categories, s)
+		}
+	}
+
+	var err error
+	if f.Tags, err = queryTags(q); err != nil {
+		return f, err
+	}
+	if f.ServiceMatch, err = parseServiceMatch(q); err != nil {
+		return f, err
+	}
+	if f.PriceMin, err = queryInt(q, "price_min"); err != nil {
+		return f, err
+	}
+	if f.PriceMax, err = queryInt(q, "price_max"); err != nil {
+		return f, err
+	}
+	if f.ActiveAt, err = queryDate(q, "active_at", false); err != nil {
+		return f, err
+	}
+	if f.StartFrom, err = queryDate(q, "start_from", false); err != nil {
+		return f, err
+	}
+	if f.StartTo, err = queryDate(q, "start_to", true); err != nil {
+		return f, err
+	}
+	if f.EndFrom, err = queryDate(q, "end_from", false); err != nil {
+		return f, err
+	}
+	if f.EndTo, err = queryDate(q, "end_to", true); err != nil {
+		return f, err
+	}
+	switch strings.TrimSpace(q.Get("has_end_date")) {
+	case "":
+	case "true":
+		v := true
+		f.HasEndDate = &v
+	case "false":
+		v := false
+		f.HasEndDate = &v
+	default:
+		return f, errors.New("bad has_end_date, use true|false")
+	}
+
+	// sort=field или field:asc|desc
+	if s := strings.TrimSpace(q.Get("sort")); s != "" {
+		field, dir, _ := strings.Cut(s, ":")
+		if !storage.ValidSort(field) {
+			return f, errors.New("bad sort field")
+		}
+		switch dir {
+		case "", "asc":
+		case "desc":
+			f.Desc = true
+		default:
+			return f, errors.New("bad sort direction, use asc|desc")
+		}
+		f.Sort = field
+	}
+	return f, nil
+}
+
+// parseSummaryFilter: параметры /subscriptions/summary из query
+func parseSummaryFilter(q url.Values) (storage.SummaryFilter, error) {
+	var f storage.SummaryFilter
+	fromS, toS := q.Get("from"), q.Get("to")
+	if fromS == "" || toS == "" {
+		return f, errors.New("from/to required MM-YYYY or YYYY-MM-DD")
+	}
+	var err error
+	if f.From, err = parseDate(fromS, false); err != nil {
+		return f, errors.New("bad from")
+	}
+	if f.To, err = parseDate(toS, true); err != nil {
+		return f, errors.New("bad to")
+	}
+	if f.To.Before(f.From) {
+		return f, errors.New("to before from")
+	}
+
+	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			return f, errors.New("bad user_id")
+		}
+		f.UserID = &u
+	}
+	if s := strings.TrimSpace(q.Get("service_name")); s != "" {
+		f.ServiceName = &s
+	}
+	if f.ServiceMatch, err = parseServiceMatch(q); err != nil {
+		return f, err
+	}
+	if s := strings.TrimSpace(q.Get("category")); s != "" {
+		f.Category = &s
+	}
+	if f.Tags, err = queryTags(q); err != nil {
+		return f, err
+	}
+
+	f.Currency = model.BaseCurrency
+	if s := strings.TrimSpace(q.Get("currency")); s != "" {
+		if f.Currency, err = parseCurrency(s); err != nil {
+			return f, errors.New("bad currency")
+		}
+	}
+	f.Mode = storage.SummaryCharges
+	if s := strings.TrimSpace(q.Get("mode")); s != "" {
+		f.Mode = storage.SummaryMode(s)
+		if !f.Mode.Valid() {
+			return f, errors.New("bad mode, use charges|amortized|prorated")
+		}
+	}
+
+	if s := strings.TrimSpace(q.Get("group_by")); s != "" {
+		seen := map[string]bool{}
+		for _, g := range strings.Split(s, ",") {
+			g = strings.TrimSpace(g)
+			if !storage.ValidGroup(g) || seen[g] {
+				return f, errors.New("bad group_by, use month,service_name,user_id,category,tag")
+			}
+			seen[g] = true
+			f.GroupBy = append(f.GroupBy, g)
+		}
+	}
+	return f, nil
+}
+
+func parseServiceMatch(q url.Values) (storage.ServiceMatch, error) {
+	m := storage.MatchExact
+	if s := strings.TrimSpace(q.Get("service_match")); s != "" {
+		m = storage.ServiceMatch(s)
+		if !m.Valid() {
+			return m, errors.New("bad service_match, use exact|ci|prefix|fuzzy")
+		}
+	}
+	return m, nil
+}
+
+func queryTags(q url.Values) ([]string, error) {
+	if len(q["tag"]) == 0 {
+		return nil, nil
+	}
+	tags, err := normalizeTags(q["tag"])
+	if err != nil {
+		return nil, errors.New("bad tag")
+	}
+	return tags, nil
+}
+
+func queryInt(q url.Values, key string) (*int, error) {
+	s := strings.TrimSpace(q.Get(key))
+	if s == "" {
+		return nil, nil
+	}
+	v, err := atoi(s)
+	if err != nil {
+		return nil, errors.New("bad " + key)
+	}
+	return &v, nil
+}
+
+func queryDate(q url.Values, key string, monthEnd bool) (*time.Time, error) {
+	s := strings.TrimSpace(q.Get(key))
+	if s == "" {
+		return nil, nil
+	}
+	t, err := parseDate(s, monthEnd)
+	if err != nil {
+		return nil, errors.New("bad " + key + ", use YYYY-MM-DD or MM-YYYY")
+	}
+	return &t, nil
+}
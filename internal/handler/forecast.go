@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const maxForecastMonths = 60
+
+// GET /subscriptions/forecast?user_id=&months=&currency=&mode=
+// Spending forecast
+// @Summary      Spending forecast
+// @Description  Прогноз расходов помесячно на months месяцев начиная с текущего. Окно начинается с сегодняшнего дня: списания, уже прошедшие в текущем месяце, не учитываются (в режиме amortized доля текущего месяца берётся целиком). Учитывает end_date (в т.ч. запланированные отмены) и будущие изменения цен из истории; считается так же, как summary
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id   query     string  false  "UUID пользователя; пусто — по всем"
+// @Param        months    query     int     false  "Количество месяцев (default 12, max 60)"
+// @Param        currency  query     string  false  "Валюта (ISO 4217, default RUB)"
+// @Param        mode      query     string  false  "charges (default) или amortized — см. summary"
+// @Success      200       {object}  model.Forecast
+// @Failure      400       {object}  map[string]string  "Bad request"
+// @Failure      422       {object}  map[string]string  "Нет курса валюты"
+// @Failure      500       {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/forecast [get]
+func (h *Handler) forecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var uid *uuid.UUID
+	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad user_id")
+			return
+		}
+		uid = &u
+	}
+	months := 12
+	if v, err := queryInt(q, "months"); err != nil || (v != nil && (*v < 1 || *v > maxForecastMonths)) {
+		writeError(w, http.StatusBadRequest, "bad months, use 1-60")
+		return
+	} else if v != nil {
+		months = *v
+	}
+	currency := model.BaseCurrency
+	if s := strings.TrimSpace(q.Get("currency")); s != "" {
+		c, err := parseCurrency(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad currency")
+			return
+		}
+		currency = c
+	}
+	mode := storage.SummaryCharges
+	if s := strings.TrimSpace(q.Get("mode")); s != "" {
+		mode = storage.SummaryMode(s)
+		if mode != storage.SummaryCharges && mode != storage.SummaryAmortized {
+			writeError(w, http.StatusBadRequest, "bad mode, use charges|amortized")
+			return
+		}
+	}
+
+	f, err := h.buildForecast(r.Context(), uid, months, currency, mode)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRate) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		h.Log.Error("forecast", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+// buildForecast: Summary с разбивкой по месяцам на окно [сегодня, конец months-го месяца];
+// списания, прошедшие с начала текущего месяца, в прогноз не входят. Месяцы без списаний дополняются нулями
+func (h *Handler) buildForecast(ctx context.Context, uid *uuid.UUID, months int, currency string, mode storage.SummaryMode) (*model.Forecast, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := monthStart(from)
+	to := first.AddDate(0, months, -1)
+	total, buckets, err := h.Repo.Summary(ctx, storage.SummaryFilter{
+		From:     from,
+		To:       to,
+		UserID:   uid,
+		Currency: currency,
+		Mode:     mode,
+		GroupBy:  []string{storage.GroupMonth},
+	})
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[string]float64, len(buckets))
+	for _, b := range buckets {
+		byMonth[*b.Month] = b.Total
+	}
+	f := &model.Forecast{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Currency: currency,
+		Mode:     string(mode),
+		Months:   make([]model.ForecastMonth, 0, months),
+		Total:    total,
+	}
+	for i := 0; i < months; i++ {
+		m := first.AddDate(0, i, 0).Format("2006-01")
+		f.Months = append(f.Months, model.ForecastMonth{Month: m, Total: byMonth[m]})
+	}
+	return f, nil
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// importFields: поля payload, которые берутся из колонок CSV (по умолчанию колонка называется так же)
+var importFields = []string{"service_name", "price", "currency", "billing_period", "billing_interval", "user_id", "start_date", "end_date", "tags"}
+
+// maxImportErrors: сколько ошибок строк попадает в отчёт
+const maxImportErrors = 1000
+
+// errImportInvalid: в файле есть невалидные строки, а skip_invalid не задан — импорт откатывается
+var errImportInvalid = errors.New("import has invalid rows")
+
+// importCSVError: файл не разбирается как CSV — дальше читать нельзя
+type importCSVError struct{ err error }
+
+func (e importCSVError) Error() string { return "bad csv: " + e.err.Error() }
+
+// POST /subscriptions/import
+// Import subscriptions from CSV
+// @Summary      Import subscriptions from CSV
+// @Description  Импорт подписок из CSV с заголовком. Колонки по умолчанию называются как поля payload (service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date, tags — через запятую), другие названия задаются map=поле:Колонка. Каждая строка проверяется так же, как в POST /subscriptions; даты — YYYY-MM-DD или MM-YYYY. dry_run=true только проверяет файл. Иначе строки вставляются через COPY одной транзакцией: если есть невалидные строки — ничего не вставляется (422), с skip_invalid=true они пропускаются
+// @Tags         subscriptions
+// @Accept       text/csv
+// @Produce      json
+// @Param        file          body      string    true   "CSV"
+// @Param        dry_run       query     bool      false  "Только проверить"
+// @Param        skip_invalid  query     bool      false  "Импортировать валидные строки, даже если есть невалидные"
+// @Param        delimiter     query     string    false  "Разделитель: , (default), ; | или tab"
+// @Param        map           query     []string  false  "Сопоставление поле:Колонка (повтор параметра), например price:Сумма"  collectionFormat(multi)
+// @Success      200           {object}  model.ImportReport
+// @Failure      400           {object}  map[string]string  "Bad request"
+// @Failure      422           {object}  model.ImportReport "Есть невалидные строки, ничего не импортировано"
+// @Failure      500           {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/import [post]
+func (h *Handler) importCSV(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dryRun := q.Get("dry_run") == "true"
+	skipInvalid := q.Get("skip_invalid") == "true"
+
+	rd := csv.NewReader(r.Body)
+	rd.FieldsPerRecord = -1
+	rd.TrimLeadingSpace = true
+	rd.ReuseRecord = true
+	switch d := q.Get("delimiter"); d {
+	case "", ",":
+	case ";", "|":
+		rd.Comma, _ = utf8.DecodeRuneInString(d)
+	case "tab", "\t":
+		rd.Comma = '\t'
+	default:
+		writeError(w, http.StatusBadRequest, "bad delimiter, use , ; | or tab")
+		return
+	}
+
+	header, err := rd.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("empty file")
+		}
+		writeError(w, http.StatusBadRequest, importCSVError{err}.Error())
+		return
+	}
+	cols, err := importColumns(header, q["map"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rep := model.ImportReport{DryRun: dryRun, Errors: []model.ImportError{}}
+	services := map[string]*model.Service{} // каталог по названию: в таблице обычно мало разных сервисов
+	users := map[uuid.UUID]*model.Subscription{}
+	next := func() (*model.Subscription, error) {
+		for {
+			rec, err := rd.Read()
+			if errors.Is(err, io.EOF) {
+				if rep.Invalid > 0 && !skipInvalid && !dryRun {
+					return nil, errImportInvalid
+				}
+				return nil, nil
+			}
+			if err != nil {
+				return nil, importCSVError{err}
+			}
+			line, _ := rd.FieldPos(0)
+			if strings.TrimSpace(strings.Join(rec, "")) == "" {
+				continue
+			}
+			rep.Rows++
+			p, s, err := importRow(rec, cols)
+			if err != nil {
+				rep.Invalid++
+				if len(rep.Errors) < maxImportErrors {
+					rep.Errors = append(rep.Errors, model.ImportError{Row: line, Error: err.Error()})
+				} else {
+					rep.ErrorsTruncated = true
+				}
+				continue
+			}
+			rep.Valid++
+			if dryRun {
+				continue
+			}
+
+			key := strings.ToLower(s.ServiceName)
+			svc, ok := services[key]
+			if !ok {
+				if svc, err = h.Repo.ResolveService(r.Context(), s.ServiceName); err != nil {
+					return nil, err
+				}
+				services[key] = svc
+			}
+			if svc != nil {
+				applyService(p, s, svc)
+			}
+			users[s.UserID] = s
+			return s, nil
+		}
+	}
+
+	if dryRun {
+		_, err = next()
+	} else {
+		rep.Imported, err = h.Repo.ImportSubscriptions(r.Context(), next)
+	}
+	var csvErr importCSVError
+	switch {
+	case errors.As(err, &csvErr):
+		writeError(w, http.StatusBadRequest, csvErr.Error())
+		return
+	case errors.Is(err, errImportInvalid):
+		writeJSON(w, http.StatusUnprocessableEntity, rep)
+		return
+	case err != nil:
+		h.Log.Error("import", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	for uid, s := range users {
+		h.checkBudgets(r.Context(), uid, s.ID)
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+// importColumns: индекс колонки для каждого поля importFields (-1 — колонки нет)
+func importColumns(header []string, mapping []string) (map[string]int, error) {
+	names := map[string]string{}
+	for _, f := range importFields {
+		names[f] = f
+	}
+	for _, m := range mapping {
+		field, col, ok := strings.Cut(m, ":")
+		if _, known := names[field]; !ok || !known || strings.TrimSpace(col) == "" {
+			return nil, errors.New("bad map " + strconv.Quote(m) + ", use field:Column")
+		}
+		names[field] = col
+	}
+
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // BOM из Excel
+	}
+	cols := map[string]int{}
+	for field, name := range names {
+		cols[field] = -1
+		for i, hdr := range header {
+			if strings.EqualFold(strings.TrimSpace(hdr), strings.TrimSpace(name)) {
+				cols[field] = i
+				break
+			}
+		}
+	}
+	for _, f := range []string{"service_name", "user_id", "start_date"} {
+		if cols[f] < 0 {
+			return nil, errors.New("missing column " + strconv.Quote(names[f]))
+		}
+	}
+	return cols, nil
+}
+
+// importRow: строка CSV в payload и подписку, с проверками parsePayload
+func importRow(rec []string, cols map[string]int) (model.SubscriptionPayload, *model.Subscription, error) {
+	get := func(field string) string {
+		if i := cols[field]; i >= 0 && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	p := model.SubscriptionPayload{
+		ServiceName:   get("service_name"),
+		Currency:      get("currency"),
+		BillingPeriod: get("billing_period"),
+		UserID:        get("user_id"),
+		StartDate:     get("start_date"),
+	}
+	if v := get("price"); v != "" {
+		price, err := strconv.Atoi(v)
+		if err != nil {
+			return p, nil, errors.New("bad price")
+		}
+		p.Price = &price
+	}
+	if v := get("billing_interval"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return p, nil, errors.New("bad billing_interval")
+		}
+		p.BillingInterval = n
+	}
+	if v := get("end_date"); v != "" {
+		p.EndDate = &v
+	}
+	if v := get("tags"); v != "" {
+		p.Tags = strings.Split(v, ",")
+	}
+	s, err := parsePayload(p)
+	return p, s, err
+}
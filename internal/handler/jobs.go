@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/jobs"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// POST /jobs
+// Create export or report job
+// @Summary      Create background job
+// @Description  Поставить в очередь выгрузку (kind=export, параметры и форматы как у GET /subscriptions/export) или отчёт о расходах (kind=report, параметры как у GET /subscriptions/summary, формат json). Параметры передаются query-строкой в поле query и проверяются сразу. Статус — GET /jobs/{id}, готовый файл — GET /jobs/{id}/download; файл хранится ограниченное время
+// @Tags         jobs
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      model.JobPayload  true  "Задача"
+// @Success      202      {object}  model.Job
+// @Header       202      {string}  Location  "/jobs/{id}"
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Failure      503      {object}  map[string]string  "Jobs unavailable"
+// @Router       /jobs [post]
+func (h *Handler) createJob(w http.ResponseWriter, r *http.Request) {
+	if h.JobsDir == "" {
+		writeError(w, http.StatusServiceUnavailable, "jobs unavailable")
+		return
+	}
+	var p model.JobPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	q, err := url.ParseQuery(p.Query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad query")
+		return
+	}
+	switch p.Kind {
+	case model.JobExport:
+		if p.Format == "" {
+			p.Format = "json"
+		}
+		if _, ok := exportFormats[p.Format]; !ok {
+			writeError(w, http.StatusBadRequest, "bad format, use csv|ndjson|json")
+			return
+		}
+		_, err = parseListFilter(q)
+	case model.JobReport:
+		if p.Format == "" {
+			p.Format = "json"
+		}
+		if p.Format != "json" {
+			writeError(w, http.StatusBadRequest, "bad format, use json")
+			return
+		}
+		_, err = parseSummaryFilter(q)
+	default:
+		writeError(w, http.StatusBadRequest, "bad kind, use export|report")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	j := &model.Job{Kind: p.Kind, Format: p.Format, Query: q.Encode()}
+	if err := h.Repo.CreateJob(r.Context(), j); err != nil {
+		h.Log.Error("create job", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+j.ID.String())
+	writeJSON(w, http.StatusAccepted, jobView(j))
+}
+
+// GET /jobs/{id}
+// Get job status
+// @Summary      Get job
+// @Description  Статус задачи (queued, running, done, failed, expired) и прогресс: processed из total подписок. У готовой задачи — download_url и expires_at
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path      string  true  "UUID задачи"
+// @Success      200  {object}  model.Job
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /jobs/{id} [get]
+func (h *Handler) getJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := h.loadJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, jobView(j))
+}
+
+// GET /jobs/{id}/download
+// Download job result
+// @Summary      Download job result
+// @Description  Файл готовой задачи. Поддерживаются Range-запросы; ответ не ограничен WriteTimeout сервера (но клиент, не читающий ответ 30 с, отключается)
+// @Tags         jobs
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        id   path      string  true  "UUID задачи"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      409  {object}  map[string]string  "Job not done"
+// @Failure      410  {object}  map[string]string  "Expired"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /jobs/{id}/download [get]
+func (h *Handler) downloadJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := h.loadJob(w, r)
+	if !ok {
+		return
+	}
+	switch j.Status {
+	case model.JobDone:
+	case model.JobExpired:
+		writeError(w, http.StatusGone, "expired")
+		return
+	default:
+		writeError(w, http.StatusConflict, "job is "+j.Status)
+		return
+	}
+	f, err := os.Open(filepath.Join(h.JobsDir, j.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusGone, "file is gone")
+		return
+	}
+	if err != nil {
+		h.Log.Error("download job", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	defer f.Close()
+
+	dw, err := newDeadlineWriter(w)
+	if err != nil {
+		h.Log.Warn("download write deadline", slog.Any("err", err))
+	}
+	name := "report." + j.Format
+	if j.Kind == model.JobExport {
+		name = "subscriptions." + j.Format
+	}
+	w.Header().Set("Content-Type", exportFormats[j.Format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	var mod time.Time
+	if j.FinishedAt != nil {
+		mod = *j.FinishedAt
+	}
+	http.ServeContent(dw, r, name, mod, f)
+}
+
+// loadJob: задача из {id}; при ошибке ответ уже записан
+func (h *Handler) loadJob(w http.ResponseWriter, r *http.Request) (*model.Job, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return nil, false
+	}
+	j, err := h.Repo.GetJob(r.Context(), id)
+	if err != nil {
+		h.Log.Error("get job", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return nil, false
+	}
+	if j == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	return j, true
+}
+
+// jobView: вычисляемые поля ответа
+func jobView(j *model.Job) *model.Job {
+	switch {
+	case j.Status == model.JobDone:
+		p := 1.0
+		j.Progress = &p
+		j.DownloadURL = "/jobs/" + j.ID.String() + "/download"
+	case j.Total != nil && *j.Total > 0:
+		p := min(float64(j.Processed)/float64(*j.Total), 1)
+		j.Progress = &p
+	}
+	return j
+}
+
+// RunJob выполняет задачу для jobs.Worker: та же выгрузка или отчёт, что у синхронных ручек, только в файл
+func (h *Handler) RunJob(ctx context.Context, j *model.Job, w io.Writer, progress jobs.Progress) error {
+	q, err := url.ParseQuery(j.Query)
+	if err != nil {
+		return errors.New("bad query")
+	}
+	lg := h.Log.With(slog.String("job", j.ID.String()))
+
+	if j.Kind == model.JobReport {
+		f, err := parseSummaryFilter(q)
+		if err != nil {
+			return err
+		}
+		total, buckets, err := h.Repo.Summary(ctx, f)
+		if errors.Is(err, storage.ErrNoRate) {
+			return err
+		}
+		if err != nil {
+			lg.Error("job report", slog.Any("err", err))
+			return errors.New("db error")
+		}
+		return json.NewEncoder(w).Encode(summaryResponse(f, total, buckets))
+	}
+
+	f, err := parseListFilter(q)
+	if err != nil {
+		return err
+	}
+	total, err := h.Repo.Count(ctx, f)
+	if err != nil {
+		lg.Error("job export count", slog.Any("err", err))
+		return errors.New("db error")
+	}
+	progress(0, &total)
+
+	bw := bufio.NewWriterSize(w, 32<<10)
+	ew := newExportWriter(j.Format, bw)
+	var n int64
+	err = ew.begin()
+	if err == nil {
+		err = h.Repo.Export(ctx, f, func(s *model.Subscription) error {
+			n++
+			progress(n, &total)
+			return ew.write(s)
+		})
+	}
+	if err == nil {
+		if err = ew.end(); err == nil {
+			err = ew.flush()
+		}
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			lg.Error("job export", slog.Int64("rows", n), slog.Any("err", err))
+		}
+		return errors.New("export failed")
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+
+	// patchRetries: сколько раз PATCH без If-Match пересчитывается, если подписку изменили между чтением и записью
+	patchRetries = 3
+)
+
+// errPatchTest: не выполнилась операция test из JSON Patch
+var errPatchTest = errors.New("patch test failed")
+
+// PATCH /subscriptions/{id}
+// Patch subscription
+// @Summary      Patch subscription
+// @Description  Частичное обновление подписки: JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json или application/json) или JSON Patch (RFC 6902, application/json-patch+json). Патч применяется к полям SubscriptionPayload текущей подписки, результат проверяется так же, как при создании. Ключ со значением null в merge patch удаляет поле (end_date: null — бессрочная подписка); для tags null значит «не менять», очистить — []. Остальные поля удалить нельзя: patch без price отклоняется с 400
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string  true   "UUID подписки"
+// @Param        patch     body      object  true   "Merge patch (объект) или JSON Patch (массив операций)"
+// @Param        If-Match  header    string  false  "ETag, с которым сделано изменение: если подписку уже изменили — 412"
+// @Success      200       {object}  model.Subscription
+// @Header       200       {string}  ETag  "Новая версия подписки"
+// @Failure      400       {object}  map[string]string  "Bad request"
+// @Failure      404       {object}  map[string]string  "Not found"
+// @Failure      409       {object}  map[string]string  "Patch test failed"
+// @Failure      412       {object}  map[string]string  "Version mismatch"
+// @Failure      415       {object}  map[string]string  "Unsupported Content-Type"
+// @Failure      500       {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id} [patch]
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "" {
+		ct = mergePatchType
+	}
+	if ct != mergePatchType && ct != jsonPatchType && ct != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "use "+mergePatchType+" or "+jsonPatchType)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	ifMatch := parseIfMatch(r)
+	for attempt := 0; ; attempt++ {
+		cur, err := h.Repo.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		if cur == nil {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		p, err := applyPatch(payloadOf(cur), ct, body)
+		if errors.Is(err, errPatchTest) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad patch: "+err.Error())
+			return
+		}
+		s, err := parsePayload(p)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.applyCatalog(r.Context(), p, s); err != nil {
+			h.Log.Error("resolve service", slog.Any("err", err))
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+
+		// без If-Match патч применяется к прочитанной версии: если её успели изменить, пересчитываем
+		expect := ifMatch
+		if expect == nil {
+			expect = []int{cur.Version}
+		}
+		ok, err := h.Repo.Update(r.Context(), id, s, expect)
+		if errors.Is(err, storage.ErrPrecondition) {
+			if ifMatch == nil && attempt < patchRetries {
+				continue
+			}
+			writeError(w, http.StatusPreconditionFailed, "version mismatch")
+			return
+		}
+		if err != nil {
+			h.Log.Error("patch", slog.Any("err", err))
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.checkBudgets(r.Context(), s.UserID, id)
+
+		res, err := h.Repo.Get(r.Context(), id)
+		if err != nil || res == nil {
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		w.Header().Set("ETag", etag(res))
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+}
+
+// payloadOf: текущая подписка в виде payload, к которому применяется патч
+func payloadOf(s *model.Subscription) model.SubscriptionPayload {
+	price := s.Price
+	p := model.SubscriptionPayload{
+		ServiceName:     s.ServiceName,
+		Price:           &price,
+		Currency:        s.Currency,
+		BillingPeriod:   string(s.BillingPeriod),
+		BillingInterval: s.BillingInterval,
+		UserID:          s.UserID.String(),
+		StartDate:       s.StartDate.Format(time.DateOnly),
+		Tags:            s.Tags,
+	}
+	if s.EndDate != nil {
+		end := s.EndDate.Format(time.DateOnly)
+		p.EndDate = &end
+	}
+	if p.Tags == nil {
+		p.Tags = []string{}
+	}
+	return p
+}
+
+// applyPatch: патч ct к payload. Неизвестные поля в результате — ошибка, чтобы опечатка не превращалась в молчаливый no-op.
+func applyPatch(p model.SubscriptionPayload, ct string, body []byte) (model.SubscriptionPayload, error) {
+	raw, _ := json.Marshal(p)
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return p, err
+	}
+
+	if ct == jsonPatchType {
+		var ops []patchOp
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return p, errors.New("want array of operations")
+		}
+		var err error
+		if doc, err = jsonPatch(doc, ops); err != nil {
+			return p, err
+		}
+	} else {
+		var patch any
+		if err := json.Unmarshal(body, &patch); err != nil {
+			return p, errors.New("invalid json")
+		}
+		if _, ok := patch.(map[string]any); !ok {
+			return p, errors.New("want json object")
+		}
+		doc = mergePatch(doc, patch)
+	}
+
+	raw, _ = json.Marshal(doc)
+	var res model.SubscriptionPayload
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&res); err != nil {
+		return p, errors.New("result is not a subscription: " + err.Error())
+	}
+	// у подписки всегда есть цена: без поля parsePayload подставил бы 0 или цену из каталога
+	if res.Price == nil {
+		return p, errors.New("price cannot be removed")
+	}
+	return res, nil
+}
+
+// mergePatch: JSON Merge Patch (RFC 7396)
+func mergePatch(doc, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	dm, ok := doc.(map[string]any)
+	if !ok {
+		dm = map[string]any{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(dm, k)
+		} else {
+			dm[k] = mergePatch(dm[k], v)
+		}
+	}
+	return dm
+}
+
+// patchOp: операция JSON Patch (RFC 6902). Value == nil — поля value нет (null приходит как "null").
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func jsonPatch(doc any, ops []patchOp) (any, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			if errors.Is(err, errPatchTest) {
+				return nil, err
+			}
+			return nil, errors.New("operation " + strconv.Itoa(i) + " (" + op.Op + "): " + err.Error())
+		}
+	}
+	return doc, nil
+}
+
+func (op patchOp) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, value)
+	case "remove":
+		return pointerRemove(doc, path)
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, errors.New("cannot move into own child")
+			}
+			if doc, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return pointerAdd(doc, path, v)
+	case "test":
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, value) {
+			return nil, errPatchTest
+		}
+		return doc, nil
+	}
+	return nil, errors.New("unknown op")
+}
+
+// parsePointer: JSON Pointer (RFC 6901) в список ключей; "" — весь документ
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, errors.New("bad path " + strconv.Quote(s))
+	}
+	parts := strings.Split(s[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func pointerGet(doc any, path []string) (any, error) {
+	for _, k := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[k]
+			if !ok {
+				return nil, errors.New("path not found")
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(k, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, errors.New("path not found")
+		}
+	}
+	return doc, nil
+}
+
+// pointerAt: применяет fn к контейнеру, в котором лежит последний ключ path, и возвращает изменённый документ
+func pointerAt(doc any, path []string, fn func(c any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch c := doc.(type) {
+	case map[string]any:
+		v, ok := c[path[0]]
+		if !ok {
+			return nil, errors.New("path not found")
+		}
+		nv, err := pointerAt(v, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = nv
+		return c, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		nv, err := pointerAt(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = nv
+		return c, nil
+	}
+	return nil, errors.New("path not found")
+}
+
+func pointerAdd(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return pointerAt(doc, path, func(c any, key string) (any, error) {
+		switch c := c.(type) {
+		case map[string]any:
+			c[key] = v
+			return c, nil
+		case []any:
+			if key == "-" {
+				return append(c, v), nil
+			}
+			i, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = v
+			return c, nil
+		}
+		return nil, errors.New("path not found")
+	})
+}
+
+func pointerRemove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove whole document")
+	}
+	return pointerAt(doc, path, func(c any, key string) (any, error) {
+		switch c := c.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return nil, errors.New("path not found")
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(key, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, errors.New("path not found")
+	})
+}
+
+// arrayIndex: индекс массива из ключа указателя, не больше max. По RFC 6901 — только цифры, без ведущих нулей и знака.
+func arrayIndex(key string, max int) (int, error) {
+	bad := key == "" || strings.TrimLeft(key, "0123456789") != "" || (len(key) > 1 && key[0] == '0')
+	i, err := strconv.Atoi(key)
+	if bad || err != nil || i > max {
+		return 0, errors.New("bad array index " + strconv.Quote(key))
+	}
+	return i, nil
+}
+
+func deepCopy(v any) any {
+	raw, _ := json.Marshal(v)
+	var res any
+	_ = json.Unmarshal(raw, &res)
+	return res
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+func mustJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad json %s: %v", s, err)
+	}
+	return v
+}
+
+// Примеры из приложения A RFC 7396
+func TestMergePatch(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doc+" + "+tt.patch, func(t *testing.T) {
+			got := mergePatch(mustJSON(t, tt.doc), mustJSON(t, tt.patch))
+			if want := mustJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// Примеры из приложения A RFC 6902 и граничные случаи указателей (RFC 6901)
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc, patch string
+		want       string // пусто — ожидается ошибка
+		testFailed bool   // ошибка должна быть errPatchTest
+	}{
+		{"A.1 add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, false},
+		{"A.2 add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, false},
+		{"A.3 remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, false},
+		{"A.4 remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, false},
+		{"A.5 replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, false},
+		{"A.6 move value",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, false},
+		{"A.7 move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`, false},
+		{"A.8 test success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, false},
+		{"A.9 test error", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, "", true},
+		{"A.10 add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`, false},
+		{"A.11 ignore unrecognized elements", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`, false},
+		{"A.12 add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, "", false},
+		{"A.14 ~ escape ordering", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, false},
+		{"A.15 comparing strings and numbers", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, "", true},
+		{"A.16 add array value", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`, false},
+		{"~1 escape", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`, false},
+		{"~0 escape", `{"m~n":1}`, `[{"op":"remove","path":"/m~0n"}]`, `{}`, false},
+		{"- appends", `{"tags":["a"]}`, `[{"op":"add","path":"/tags/-","value":"b"}]`, `{"tags":["a","b"]}`, false},
+		{"- is not an existing element", `{"tags":["a"]}`, `[{"op":"remove","path":"/tags/-"}]`, "", false},
+		{"add at end index", `{"tags":["a"]}`, `[{"op":"add","path":"/tags/1","value":"b"}]`, `{"tags":["a","b"]}`, false},
+		{"add past end", `{"tags":["a"]}`, `[{"op":"add","path":"/tags/2","value":"b"}]`, "", false},
+		{"leading zero index", `{"tags":["a","b"]}`, `[{"op":"remove","path":"/tags/01"}]`, "", false},
+		{"signed index", `{"tags":["a","b"]}`, `[{"op":"remove","path":"/tags/+1"}]`, "", false},
+		{"zero index", `{"tags":["a","b"]}`, `[{"op":"remove","path":"/tags/0"}]`, `{"tags":["b"]}`, false},
+		{"copy is deep", `{"a":{"x":1}}`, `[{"op":"copy","from":"/a","path":"/b"},{"op":"replace","path":"/b/x","value":2}]`,
+			`{"a":{"x":1},"b":{"x":2}}`, false},
+		{"move into own child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, "", false},
+		{"replace missing", `{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`, "", false},
+		{"missing value", `{"a":1}`, `[{"op":"add","path":"/b"}]`, "", false},
+		{"null value", `{"a":1}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`, false},
+		{"unknown op", `{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`, "", false},
+		{"bad pointer", `{"a":1}`, `[{"op":"remove","path":"a"}]`, "", false},
+		{"operations after a failed test are not applied", `{"a":1}`,
+			`[{"op":"test","path":"/a","value":2},{"op":"remove","path":"/missing"}]`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []patchOp
+			if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonPatch(mustJSON(t, tt.doc), ops)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				if errors.Is(err, errPatchTest) != tt.testFailed {
+					t.Fatalf("err = %v, want errPatchTest = %v", err, tt.testFailed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := mustJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	price, end := 499, "2026-12-31"
+	base := model.SubscriptionPayload{
+		ServiceName:     "Netflix",
+		Price:           &price,
+		Currency:        "RUB",
+		BillingPeriod:   "monthly",
+		BillingInterval: 1,
+		UserID:          "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:       "2026-01-01",
+		EndDate:         &end,
+		Tags:            []string{"video"},
+	}
+	tests := []struct {
+		name, ct, body string
+		check          func(t *testing.T, p model.SubscriptionPayload)
+		testFailed     bool // nil check и не testFailed — ожидается ошибка разбора
+	}{
+		{"merge changes price", mergePatchType, `{"price":599}`, func(t *testing.T, p model.SubscriptionPayload) {
+			if *p.Price != 599 || p.ServiceName != "Netflix" || p.EndDate == nil {
+				t.Errorf("got %+v", p)
+			}
+		}, false},
+		{"application/json is merge patch", "application/json", `{"service_name":"Kinopoisk"}`, func(t *testing.T, p model.SubscriptionPayload) {
+			if p.ServiceName != "Kinopoisk" {
+				t.Errorf("service_name = %q", p.ServiceName)
+			}
+		}, false},
+		{"merge null removes end_date", mergePatchType, `{"end_date":null}`, func(t *testing.T, p model.SubscriptionPayload) {
+			if p.EndDate != nil {
+				t.Errorf("end_date = %q, want nil", *p.EndDate)
+			}
+		}, false},
+		{"merge null price", mergePatchType, `{"price":null}`, nil, false},
+		{"merge unknown field", mergePatchType, `{"prise":599}`, nil, false},
+		{"merge not an object", mergePatchType, `[{"op":"remove","path":"/end_date"}]`, nil, false},
+		{"merge invalid json", mergePatchType, `{`, nil, false},
+		{"json patch appends tag", jsonPatchType, `[{"op":"add","path":"/tags/-","value":"family"}]`, func(t *testing.T, p model.SubscriptionPayload) {
+			if !reflect.DeepEqual(p.Tags, []string{"video", "family"}) {
+				t.Errorf("tags = %v", p.Tags)
+			}
+		}, false},
+		{"json patch guarded by test", jsonPatchType,
+			`[{"op":"test","path":"/price","value":499},{"op":"replace","path":"/price","value":599}]`,
+			func(t *testing.T, p model.SubscriptionPayload) {
+				if *p.Price != 599 {
+					t.Errorf("price = %d", *p.Price)
+				}
+			}, false},
+		{"json patch failed test", jsonPatchType, `[{"op":"test","path":"/price","value":100}]`, nil, true},
+		{"json patch wrong type", jsonPatchType, `[{"op":"replace","path":"/price","value":"free"}]`, nil, false},
+		{"json patch removes price", jsonPatchType, `[{"op":"remove","path":"/price"}]`, nil, false},
+		{"json patch not an array", jsonPatchType, `{"price":599}`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := applyPatch(base, tt.ct, []byte(tt.body))
+			if tt.check == nil {
+				if err == nil {
+					t.Fatalf("got %+v, want error", p)
+				}
+				if errors.Is(err, errPatchTest) != tt.testFailed {
+					t.Fatalf("err = %v, want errPatchTest = %v", err, tt.testFailed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, p)
+		})
+	}
+	if *base.Price != 499 || len(base.Tags) != 1 {
+		t.Errorf("base payload modified: %+v", base)
+	}
+}
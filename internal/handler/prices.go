@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GET /subscriptions/{id}/prices
+// List price history
+// @Summary      List price history
+// @Description  История цен подписки: какая цена действовала с какого месяца
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id   path      string  true  "UUID подписки"
+// @Success      200  {array}   model.PriceChange
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/prices [get]
+func (h *Handler) listPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	items, err := h.Repo.ListPrices(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if items == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// POST /subscriptions/{id}/prices
+// Add price change
+// @Summary      Add price change
+// @Description  Новая цена подписки начиная с даты valid_from; суммы за более ранние даты не меняются
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "UUID подписки"
+// @Param        payload  body      model.PriceChangePayload  true  "Цена и месяц начала действия"
+// @Success      201      {array}   model.PriceChange  "Обновлённая история цен"
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/prices [post]
+func (h *Handler) addPrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	var p model.PriceChangePayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if p.Price < 0 || p.ValidFrom == "" {
+		writeError(w, http.StatusBadRequest, "missing required fields")
+		return
+	}
+	from, err := parseDate(p.ValidFrom, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad valid_from, use YYYY-MM-DD or MM-YYYY")
+		return
+	}
+
+	s, err := h.Repo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if from.Before(monthStart(s.StartDate)) {
+		writeError(w, http.StatusBadRequest, "valid_from before start_date")
+		return
+	}
+
+	ok, err := h.Repo.AddPrice(r.Context(), id, p.Price, from)
+	if err != nil {
+		h.Log.Error("add price", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	h.checkBudgets(r.Context(), s.UserID, id)
+	items, err := h.Repo.ListPrices(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, items)
+}
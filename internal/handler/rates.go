@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+// adminOnly: если задан AdminToken, требует заголовок Authorization: Bearer <token>
+func (h *Handler) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.AdminToken != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(h.AdminToken)) != 1 {
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminRequired: как adminOnly, но без AdminToken ручки закрыты, а не открыты всем
+func (h *Handler) adminRequired(next http.Handler) http.Handler {
+	guarded := h.adminOnly(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.AdminToken == "" {
+			writeError(w, http.StatusForbidden, "admin token is not configured")
+			return
+		}
+		guarded.ServeHTTP(w, r)
+	})
+}
+
+// POST /admin/exchange-rates
+// Upload exchange rates
+// @Summary      Upload exchange rates
+// @Description  Загрузить курсы валют к RUB с датой начала действия; существующие (currency, valid_from) перезаписываются
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      []model.ExchangeRatePayload  true  "Курсы"
+// @Success      200      {object}  map[string]int     "Количество загруженных курсов, ключ loaded"
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /admin/exchange-rates [post]
+func (h *Handler) uploadRates(w http.ResponseWriter, r *http.Request) {
+	var ps []model.ExchangeRatePayload
+	if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	rates, err := parseRates(ps)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.Repo.UpsertRates(r.Context(), rates); err != nil {
+		h.Log.Error("upload rates", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"loaded": len(rates)})
+}
+
+// GET /admin/exchange-rates?currency=
+// List exchange rates
+// @Summary      List exchange rates
+// @Description  Список загруженных курсов валют
+// @Tags         admin
+// @Produce      json
+// @Param        currency  query     string  false  "Фильтр по валюте"
+// @Success      200       {array}   model.ExchangeRate
+// @Failure      400       {object}  map[string]string  "Bad request"
+// @Failure      401       {object}  map[string]string  "Unauthorized"
+// @Failure      500       {object}  map[string]string  "Internal error"
+// @Router       /admin/exchange-rates [get]
+func (h *Handler) listRates(w http.ResponseWriter, r *http.Request) {
+	var currency *string
+	if s := strings.TrimSpace(r.URL.Query().Get("currency")); s != "" {
+		c, err := parseCurrency(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad currency")
+			return
+		}
+		currency = &c
+	}
+	items, err := h.Repo.ListRates(r.Context(), currency)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// LoadRatesFile загружает курсы из локального JSON-файла (формат как у POST /admin/exchange-rates)
+func (h *Handler) LoadRatesFile(ctx context.Context, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var ps []model.ExchangeRatePayload
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return 0, fmt.Errorf("rates file %s: %w", path, err)
+	}
+	rates, err := parseRates(ps)
+	if err != nil {
+		return 0, fmt.Errorf("rates file %s: %w", path, err)
+	}
+	if err := h.Repo.UpsertRates(ctx, rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+func parseRates(ps []model.ExchangeRatePayload) ([]model.ExchangeRate, error) {
+	rates := make([]model.ExchangeRate, 0, len(ps))
+	for i, p := range ps {
+		c, err := parseCurrency(p.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: bad currency", i)
+		}
+		if c == model.BaseCurrency {
+			return nil, fmt.Errorf("item %d: %s is the base currency", i, c)
+		}
+		if p.Rate <= 0 {
+			return nil, fmt.Errorf("item %d: rate must be positive", i)
+		}
+		from, err := time.Parse(time.DateOnly, p.ValidFrom)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: bad valid_from, use YYYY-MM-DD", i)
+		}
+		rates = append(rates, model.ExchangeRate{Currency: c, Rate: p.Rate, ValidFrom: from})
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("no rates")
+	}
+	return rates, nil
+}
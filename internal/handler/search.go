@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GET /subscriptions/service-names?q=&limit=
+// Autocomplete service names
+// @Summary      Autocomplete service names
+// @Description  Известные названия сервисов: сначала начинающиеся с q (без учёта регистра), затем похожие; внутри — по числу пользователей
+// @Tags         subscriptions
+// @Produce      json
+// @Param        q      query     string  false  "Начало или часть названия; пусто — самые популярные"
+// @Param        limit  query     int     false  "Количество (default 10, max 50)"
+// @Success      200    {array}   model.ServiceNameSuggestion
+// @Failure      500    {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/service-names [get]
+func (h *Handler) serviceNames(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 10
+	if s := strings.TrimSpace(q.Get("limit")); s != "" {
+		if v, err := atoi(s); err == nil && v > 0 && v <= 50 {
+			limit = v
+		}
+	}
+	items, err := h.Repo.ServiceNames(r.Context(), q.Get("q"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// applyCatalog: если service_name есть в каталоге — подставляет каноническое название и service_id,
+// а для не заданных в payload цены и валюты берёт значения каталога
+func (h *Handler) applyCatalog(ctx context.Context, p model.SubscriptionPayload, s *model.Subscription) error {
+	svc, err := h.Repo.ResolveService(ctx, s.ServiceName)
+	if err != nil || svc == nil {
+		return err
+	}
+	applyService(p, s, svc)
+	return nil
+}
+
+// applyService: часть applyCatalog для уже найденной записи каталога
+func applyService(p model.SubscriptionPayload, s *model.Subscription, svc *model.Service) {
+	s.ServiceName = svc.Name
+	s.ServiceID = &svc.ID
+	if p.Price == nil && svc.DefaultPrice != nil {
+		s.Price = *svc.DefaultPrice
+		if p.Currency == "" {
+			s.Currency = svc.Currency
+		}
+	}
+}
+
+// POST /services
+// Create catalog service
+// @Summary      Create catalog service
+// @Description  Добавить сервис в каталог. Уже существующие подписки с таким названием или алиасом привязываются к нему
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      model.ServicePayload  true  "Сервис"
+// @Success      201      {object}  model.Service
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      409      {object}  map[string]string  "Название или алиас заняты"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /services [post]
+func (h *Handler) createService(w http.ResponseWriter, r *http.Request) {
+	var p model.ServicePayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	s, err := parseServicePayload(p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.Repo.CreateService(r.Context(), s); err != nil {
+		h.serviceError(w, "create service", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// GET /services?category=
+// List catalog services
+// @Summary      List catalog services
+// @Description  Каталог сервисов по названию
+// @Tags         services
+// @Produce      json
+// @Param        category  query     string  false  "Фильтр по категории"
+// @Success      200       {array}   model.Service
+// @Failure      500       {object}  map[string]string  "Internal error"
+// @Router       /services [get]
+func (h *Handler) listServices(w http.ResponseWriter, r *http.Request) {
+	var category *string
+	if s := strings.TrimSpace(r.URL.Query().Get("category")); s != "" {
+		category = &s
+	}
+	items, err := h.Repo.ListServices(r.Context(), category)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GET /services/{id}
+// Get catalog service
+// @Summary      Get catalog service
+// @Description  Сервис каталога по идентификатору
+// @Tags         services
+// @Produce      json
+// @Param        id   path      string  true  "UUID сервиса"
+// @Success      200  {object}  model.Service
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /services/{id} [get]
+func (h *Handler) getService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	s, err := h.Repo.GetService(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// PUT /services/{id}
+// Update catalog service
+// @Summary      Update catalog service
+// @Description  Обновить сервис каталога. Подписки, совпавшие с новыми названием или алиасами, привязываются к нему
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                true  "UUID сервиса"
+// @Param        payload  body      model.ServicePayload  true  "Сервис"
+// @Success      200      {object}  model.Service
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      409      {object}  map[string]string  "Название или алиас заняты"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /services/{id} [put]
+func (h *Handler) updateService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	var p model.ServicePayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	s, err := parseServicePayload(p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ok, err := h.Repo.UpdateService(r.Context(), id, s)
+	if err != nil {
+		h.serviceError(w, "update service", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// DELETE /services/{id}
+// Delete catalog service
+// @Summary      Delete catalog service
+// @Description  Удалить сервис из каталога; подписки остаются, но отвязываются от него
+// @Tags         services
+// @Param        id   path      string  true  "UUID сервиса"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /services/{id} [delete]
+func (h *Handler) deleteService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	ok, err := h.Repo.DeleteService(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) serviceError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, storage.ErrConflict) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	h.Log.Error(op, slog.Any("err", err))
+	writeError(w, http.StatusInternalServerError, "db error")
+}
+
+func parseServicePayload(p model.ServicePayload) (*model.Service, error) {
+	s := &model.Service{
+		Name:         strings.TrimSpace(p.Name),
+		Aliases:      []string{},
+		DefaultPrice: p.DefaultPrice,
+		Currency:     model.BaseCurrency,
+	}
+	if s.Name == "" {
+		return nil, errors.New("missing required fields")
+	}
+	seen := map[string]bool{strings.ToLower(s.Name): true}
+	for _, a := range p.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		s.Aliases = append(s.Aliases, a)
+	}
+	if p.Category != nil {
+		if c := strings.TrimSpace(*p.Category); c != "" {
+			s.Category = &c
+		}
+	}
+	if p.DefaultPrice != nil && *p.DefaultPrice < 0 {
+		return nil, errors.New("bad default_price")
+	}
+	if p.Currency != "" {
+		c, err := parseCurrency(p.Currency)
+		if err != nil {
+			return nil, errors.New("bad currency, use ISO 4217 code")
+		}
+		s.Currency = c
+	}
+	if p.Homepage != nil {
+		if hp := strings.TrimSpace(*p.Homepage); hp != "" {
+			u, err := url.Parse(hp)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, errors.New("bad homepage, use http(s) URL")
+			}
+			s.Homepage = &hp
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// GET /subscriptions/stream?user_id=
+// Subscription change stream
+// @Summary      Subscription change stream
+// @Description  Server-Sent Events с изменениями подписок (события subscription.created / updated / deleted, id события — id изменения). Поддерживает продолжение по заголовку Last-Event-ID (или параметру last_event_id) в пределах суток
+// @Tags         subscriptions
+// @Produce      text/event-stream
+// @Param        user_id        query     string  false  "Только изменения подписок этого пользователя"
+// @Param        last_event_id  query     int     false  "Продолжить после этого события (если нельзя передать заголовок Last-Event-ID)"
+// @Success      200            {string}  string  "event stream"
+// @Failure      400            {object}  map[string]string  "Bad request"
+// @Failure      503            {object}  map[string]string  "Stream unavailable"
+// @Router       /subscriptions/stream [get]
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request) {
+	if h.Stream == nil {
+		writeError(w, http.StatusServiceUnavailable, "stream unavailable")
+		return
+	}
+	q := r.URL.Query()
+	var uid *uuid.UUID
+	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad user_id")
+			return
+		}
+		uid = &u
+	}
+	var lastEventID int64
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = q.Get("last_event_id")
+	}
+	if s := strings.TrimSpace(lastID); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 0 {
+			writeError(w, http.StatusBadRequest, "bad Last-Event-ID")
+			return
+		}
+		lastEventID = v
+	}
+	var sent storage.ChangePos // позиция последнего отправленного изменения
+	if lastEventID > 0 {
+		p, err := h.Repo.ChangePosByID(r.Context(), lastEventID)
+		if err != nil {
+			h.Log.Error("stream position", slog.Any("err", err))
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		sent = p
+	}
+
+	dw, err := newDeadlineWriter(w)
+	if err != nil {
+		h.Log.Warn("stream write deadline", slog.Any("err", err))
+	}
+
+	// подписываемся до догрузки истории, чтобы не потерять изменения между ними; дубли отсекаются по id
+	sub := h.Stream.Subscribe(uid)
+	defer h.Stream.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(dw, "retry: 3000\n\n")
+
+	write := func(c model.SubscriptionChange) error {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(dw, "id: %d\nevent: subscription.%s\ndata: %s\n\n", c.ID, c.Op, data); err != nil {
+			return err
+		}
+		sent = storage.PosOf(c)
+		return nil
+	}
+
+	if lastEventID > 0 {
+		for {
+			items, err := h.Repo.ChangesSince(r.Context(), sent, uid, 500)
+			if err != nil {
+				h.Log.Warn("stream replay", slog.Any("err", err))
+				return
+			}
+			for _, c := range items {
+				if err := write(c); err != nil {
+					return
+				}
+			}
+			if len(items) < 500 {
+				break
+			}
+		}
+	}
+	if err := dw.Flush(); err != nil {
+		return
+	}
+
+	t := time.NewTicker(streamHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+			if _, err := fmt.Fprint(dw, ": ping\n\n"); err != nil {
+				return
+			}
+		case c, ok := <-sub.C:
+			if !ok {
+				return // не успеваем — клиент переподключится с Last-Event-ID
+			}
+			if !sent.Before(storage.PosOf(c)) {
+				continue
+			}
+			if err := write(c); err != nil {
+				return
+			}
+		}
+		if err := dw.Flush(); err != nil {
+			return
+		}
+	}
+}
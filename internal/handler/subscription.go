@@ -6,19 +6,26 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
 	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/stream"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type Handler struct {
-	Repo *storage.Repository
-	Log  *slog.Logger
+	Repo       *storage.Repository
+	Log        *slog.Logger
+	AdminToken string      // пустой — admin-ручки без авторизации, а /webhooks закрыт
+	Stream     *stream.Hub // nil — /subscriptions/stream недоступен
+	JobsDir    string      // каталог файлов фоновых задач, пустой — /jobs недоступен
+	// AllowPrivateWebhooks: разрешить вебхуки на localhost и частные сети (разработка)
+	AllowPrivateWebhooks bool
 }
 
 func New(r *storage.Repository, lg *slog.Logger) *Handler {
@@ -27,19 +34,76 @@ func New(r *storage.Repository, lg *slog.Logger) *Handler {
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	r.Route("/subscriptions", func(r chi.Router) {
+		r.Use(auditMeta)
 		r.Post("/", h.create)
+		r.Post("/batch", h.batch)
+		r.Post("/import", h.importCSV)
+		r.Get("/export", h.export)
 		r.Get("/", h.list)
+		r.Get("/trash", h.trash)
 		r.Get("/{id}", h.get)
 		r.Put("/{id}", h.update)
+		r.Patch("/{id}", h.patch)
 		r.Delete("/{id}", h.delete)
+		r.Post("/{id}/restore", h.restore)
+		r.Post("/{id}/tags", h.addTags)
+		r.Delete("/{id}/tags/{tag}", h.removeTag)
+		r.Get("/{id}/history", h.history)
+		r.Get("/{id}/prices", h.listPrices)
+		r.Post("/{id}/prices", h.addPrice)
 		r.Get("/summary", h.summary)
+		r.Get("/upcoming", h.upcoming)
+		r.Get("/forecast", h.forecast)
+		r.Get("/stream", h.stream)
+		r.Get("/service-names", h.serviceNames)
+	})
+	r.Get("/tags", h.listTags)
+	r.Post("/users/{user_id}/calendar-feed", h.issueCalendarFeed)
+	r.Delete("/users/{user_id}/calendar-feed", h.revokeCalendarFeed)
+	r.Get("/calendar/{token}.ics", h.calendarFeed)
+	r.Route("/budgets", func(r chi.Router) {
+		r.Post("/", h.createBudget)
+		r.Get("/", h.listBudgets)
+		r.Get("/status", h.budgetStatus)
+		r.Get("/breaches", h.listBreaches)
+		r.Get("/{id}", h.getBudget)
+		r.Put("/{id}", h.updateBudget)
+		r.Delete("/{id}", h.deleteBudget)
+	})
+	r.Route("/services", func(r chi.Router) {
+		r.Get("/", h.listServices)
+		r.Get("/{id}", h.getService)
+		r.With(h.adminOnly).Post("/", h.createService)
+		r.With(h.adminOnly).Put("/{id}", h.updateService)
+		r.With(h.adminOnly).Delete("/{id}", h.deleteService)
+	})
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(h.adminRequired)
+		r.Post("/", h.createWebhook)
+		r.Get("/", h.listWebhooks)
+		r.Get("/{id}", h.getWebhook)
+		r.Put("/{id}", h.updateWebhook)
+		r.Delete("/{id}", h.deleteWebhook)
+		r.Get("/{id}/deliveries", h.listDeliveries)
+		r.Post("/{id}/deliveries/{delivery_id}/retry", h.retryDelivery)
+	})
+	r.Route("/jobs", func(r chi.Router) {
+		r.Post("/", h.createJob)
+		r.Get("/{id}", h.getJob)
+		r.Get("/{id}/download", h.downloadJob)
+	})
+	r.With(h.adminOnly).Get("/audit", h.audit)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(h.adminOnly)
+		r.Post("/exchange-rates", h.uploadRates)
+		r.Get("/exchange-rates", h.listRates)
 	})
 }
 
 // POST /subscriptions
 // Create subscription
 // @Summary      Create subscription
-// @Description  Создать новую подписку
+// @Description  Создать новую подписку. service_name сопоставляется с каталогом сервисов (название или алиас без учёта регистра) и заменяется каноническим; без price берётся default_price из каталога
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
@@ -54,48 +118,23 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	p.ServiceName = strings.TrimSpace(p.ServiceName)
-	if p.ServiceName == "" || p.Price < 0 || p.UserID == "" || p.StartDate == "" {
-		writeError(w, http.StatusBadRequest, "missing required fields")
-		return
-	}
-	uid, err := uuid.Parse(p.UserID)
+	s, err := parsePayload(p)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad user_id")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	start, err := parseMonthYear(p.StartDate)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad start_date, use MM-YYYY")
+	if err := h.applyCatalog(r.Context(), p, s); err != nil {
+		h.Log.Error("resolve service", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	var end *time.Time
-	if p.EndDate != nil && *p.EndDate != "" {
-		e, err := parseMonthYear(*p.EndDate)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "bad end_date, use MM-YYYY")
-			return
-		}
-		end = &e
-		if end.Before(start) {
-			writeError(w, http.StatusBadRequest, "end_date before start_date")
-			return
-		}
-	}
-
-	s := &model.Subscription{
-		ServiceName: p.ServiceName,
-		Price:       p.Price,
-		UserID:      uid,
-		StartDate:   start,
-		EndDate:     end,
-	}
 	id, err := h.Repo.Create(r.Context(), s)
 	if err != nil {
 		h.Log.Error("create", slog.Any("err", err))
 		writeError(w, http.StatusInternalServerError, "db error")
 		return
 	}
+	h.checkBudgets(r.Context(), s.UserID, id)
 
 	writeJSON(w, http.StatusCreated, map[string]any{"id": id})
 }
@@ -106,8 +145,11 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 // @Description  Получить подписку по идентификатору
 // @Tags         subscriptions
 // @Produce      json
-// @Param        id   path      string  true  "UUID подписки"
+// @Param        id             path      string  true   "UUID подписки"
+// @Param        If-None-Match  header    string  false  "ETag из предыдущего ответа: если подписка не менялась — 304"
 // @Success      200  {object}  model.Subscription
+// @Header       200  {string}  ETag  "Версия подписки"
+// @Success      304  {string}  string  "Not Modified"
 // @Failure      400  {object}  map[string]string  "Bad request"
 // @Failure      404  {object}  map[string]string  "Not found"
 // @Failure      500  {object}  map[string]string  "Internal error"
@@ -128,21 +170,30 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "not found")
 		return
 	}
+	tag := etag(s)
+	w.Header().Set("ETag", tag)
+	if notModified(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	writeJSON(w, http.StatusOK, s)
 }
 
 // PUT /subscriptions/{id}
 // Update subscription
 // @Summary      Update subscription
-// @Description  Обновить подписку по идентификатору
+// @Description  Обновить подписку по идентификатору. Новая цена действует с текущего месяца, история цен сохраняется
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string                     true  "UUID подписки"
 // @Param        payload  body      model.SubscriptionPayload  true  "Новые значения полей"
+// @Param        If-Match header    string                     false  "ETag, с которым сделано изменение: если подписку уже изменили — 412"
 // @Success      200      {object}  model.Subscription
+// @Header       200      {string}  ETag  "Новая версия подписки"
 // @Failure      400      {object}  map[string]string  "Bad request"
 // @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      412      {object}  map[string]string  "Version mismatch"
 // @Failure      500      {object}  map[string]string  "Internal error"
 // @Router       /subscriptions/{id} [put]
 func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
@@ -158,43 +209,21 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	p.ServiceName = strings.TrimSpace(p.ServiceName)
-	if p.ServiceName == "" || p.Price < 0 || p.UserID == "" || p.StartDate == "" {
-		writeError(w, http.StatusBadRequest, "missing required fields")
-		return
-	}
-	uid, err := uuid.Parse(p.UserID)
+	s, err := parsePayload(p)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad user_id")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	start, err := parseMonthYear(p.StartDate)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad start_date, use MM-YYYY")
+	if err := h.applyCatalog(r.Context(), p, s); err != nil {
+		h.Log.Error("resolve service", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	var end *time.Time
-	if p.EndDate != nil && *p.EndDate != "" {
-		e, err := parseMonthYear(*p.EndDate)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "bad end_date, use MM-YYYY")
-			return
-		}
-		end = &e
-		if end.Before(start) {
-			writeError(w, http.StatusBadRequest, "end_date before start_date")
-			return
-		}
+	ok, err := h.Repo.Update(r.Context(), id, s, parseIfMatch(r))
+	if errors.Is(err, storage.ErrPrecondition) {
+		writeError(w, http.StatusPreconditionFailed, "version mismatch")
+		return
 	}
-
-	s := &model.Subscription{
-		ServiceName: p.ServiceName,
-		Price:       p.Price,
-		UserID:      uid,
-		StartDate:   start,
-		EndDate:     end,
-	}
-	ok, err := h.Repo.Update(r.Context(), id, s)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "db error")
 		return
@@ -203,18 +232,22 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "not found")
 		return
 	}
+	h.checkBudgets(r.Context(), s.UserID, id)
+	w.Header().Set("ETag", etag(s))
 	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
 // DELETE /subscriptions/{id}
 // Delete subscription
 // @Summary      Delete subscription
-// @Description  Удалить подписку по идентификатору
+// @Description  Перенести подписку в корзину: она пропадает из списков и сводок, но её можно восстановить до окончательной очистки (trash.retention)
 // @Tags         subscriptions
-// @Param        id   path      string  true  "UUID подписки"
+// @Param        id        path      string  true   "UUID подписки"
+// @Param        If-Match  header    string  false  "ETag, с которым сделано удаление: если подписку уже изменили — 412"
 // @Success      204  {string}  string  "No Content"
 // @Failure      400  {object}  map[string]string  "Bad request"
 // @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      412  {object}  map[string]string  "Version mismatch"
 // @Failure      500  {object}  map[string]string  "Internal error"
 // @Router       /subscriptions/{id} [delete]
 func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
@@ -224,7 +257,11 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "bad id")
 		return
 	}
-	ok, err := h.Repo.Delete(r.Context(), id)
+	ok, err := h.Repo.Delete(r.Context(), id, parseIfMatch(r))
+	if errors.Is(err, storage.ErrPrecondition) {
+		writeError(w, http.StatusPreconditionFailed, "version mismatch")
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "db error")
 		return
@@ -239,36 +276,49 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 // GET /subscriptions
 // List subscriptions
 // @Summary      List subscriptions
-// @Description  Список подписок с фильтрами и пагинацией
+// @Description  Список подписок (новые сначала) с фильтрами и пагинацией: по курсору (next_cursor) или, для совместимости, через offset. Ссылки на страницы также в заголовке Link (RFC 8288)
 // @Tags         subscriptions
 // @Produce      json
-// @Param        user_id       query     string  false  "Фильтр по UUID пользователя"
-// @Param        service_name  query     string  false  "Фильтр по названию сервиса"
-// @Param        limit         query     int     false  "Количество записей (default 20, max 100)"
-// @Param        offset        query     int     false  "Смещение от начала списка"
-// @Success      200           {array}   model.Subscription
-// @Failure      400           {object}  map[string]string  "Bad request"
-// @Failure      500           {object}  map[string]string  "Internal error"
+// @Param        user_id        query     []string  false  "UUID пользователей (повтор параметра или через запятую)"  collectionFormat(multi)
+// @Param        service_name   query     []string  false  "Названия сервисов (повтор параметра)"  collectionFormat(multi)
+// @Param        service_match  query     string  false  "Сравнение service_name: exact (default), ci — без учёта регистра, prefix, fuzzy — триграммное сходство"
+// @Param        category       query     []string  false  "Категории каталога сервисов (повтор параметра)"  collectionFormat(multi)
+// @Param        tag            query     []string  false  "Есть любой из тегов (повтор параметра)"  collectionFormat(multi)
+// @Param        price_min      query     int     false  "Цена от"
+// @Param        price_max      query     int     false  "Цена до"
+// @Param        active_at      query     string  false  "Активна в этот день (YYYY-MM-DD или MM-YYYY)"
+// @Param        start_from     query     string  false  "start_date не раньше"
+// @Param        start_to       query     string  false  "start_date не позже"
+// @Param        end_from       query     string  false  "end_date не раньше"
+// @Param        end_to         query     string  false  "end_date не позже"
+// @Param        has_end_date   query     bool    false  "Есть ли end_date"
+// @Param        sort           query     string  false  "Поле[:asc|desc]: created_at, updated_at, start_date, end_date, price, service_name (default created_at:desc)"
+// @Param        limit          query     int     false  "Количество записей (default 50, max 200)"
+// @Param        cursor         query     string  false  "Курсор следующей страницы (next_cursor из предыдущего ответа)"
+// @Param        offset         query     int     false  "Смещение от начала списка (игнорируется при cursor)"
+// @Param        include_total  query     bool    false  "Посчитать total — общее количество по фильтру"
+// @Success      200            {object}  model.SubscriptionList
+// @Header       200            {string}  Link  "rel=next / rel=prev / rel=first"
+// @Failure      400            {object}  map[string]string  "Bad request"
+// @Failure      500            {object}  map[string]string  "Internal error"
 // @Router       /subscriptions [get]
 func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	h.writeList(w, r, false)
+}
+
+// writeList: страница списка подписок (или корзины, если deleted) по фильтрам и пагинации из query
+func (h *Handler) writeList(w http.ResponseWriter, r *http.Request, deleted bool) {
 	q := r.URL.Query()
+	f, err := parseListFilter(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.Deleted = deleted
 	var (
-		uid     *uuid.UUID
-		service *string
-		limit   = 50
-		offset  = 0
+		limit  = 50
+		offset = 0
 	)
-	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
-		u, err := uuid.Parse(s)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "bad user_id")
-			return
-		}
-		uid = &u
-	}
-	if s := strings.TrimSpace(q.Get("service_name")); s != "" {
-		service = &s
-	}
 	if s := strings.TrimSpace(q.Get("limit")); s != "" {
 		if v, err := atoi(s); err == nil && v > 0 && v <= 200 {
 			limit = v
@@ -279,74 +329,220 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 			offset = v
 		}
 	}
+	cursor := strings.TrimSpace(q.Get("cursor"))
+	if cursor != "" {
+		offset = 0
+	}
 
-	items, err := h.Repo.List(r.Context(), storage.ListFilter{UserID: uid, ServiceName: service, Limit: limit, Offset: offset})
+	f.Limit, f.Offset, f.Cursor = limit, offset, cursor
+	items, next, err := h.Repo.List(r.Context(), f)
 	if err != nil {
+		if errors.Is(err, storage.ErrBadCursor) {
+			writeError(w, http.StatusBadRequest, "bad cursor")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	writeJSON(w, http.StatusOK, items)
+	resp := model.SubscriptionList{Items: items, NextCursor: next}
+	if q.Get("include_total") == "true" {
+		total, err := h.Repo.Count(r.Context(), f)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		resp.Total = &total
+	}
+
+	w.Header().Set("Link", listLinks(r, limit, offset, cursor, next))
+	writeJSON(w, http.StatusOK, resp)
 }
 
-// GET /subscriptions/summary?from=MM-YYYY&to=MM-YYYY&user_id=&service_name=
+// listLinks: заголовок Link (RFC 8288) для страницы списка. В режиме offset ссылки тоже через offset,
+// иначе next — по курсору; prev по курсору не строится (курсор односторонний).
+func listLinks(r *http.Request, limit, offset int, cursor, next string) string {
+	link := func(rel string, set map[string]string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Del("cursor")
+		q.Del("offset")
+		q.Set("limit", strconv.Itoa(limit))
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+		return "<" + u.RequestURI() + `>; rel="` + rel + `"`
+	}
+
+	links := []string{link("first", nil)}
+	offsetMode := cursor == "" && r.URL.Query().Has("offset")
+	if next != "" {
+		if offsetMode {
+			links = append(links, link("next", map[string]string{"offset": strconv.Itoa(offset + limit)}))
+		} else {
+			links = append(links, link("next", map[string]string{"cursor": next}))
+		}
+	}
+	if offsetMode && offset > 0 {
+		links = append(links, link("prev", map[string]string{"offset": strconv.Itoa(max(offset-limit, 0))}))
+	}
+	return strings.Join(links, ", ")
+}
+
+// GET /subscriptions/summary?from=&to=&user_id=&service_name=&currency=&mode=&group_by=
 // Summary of subscriptions cost
 // @Summary      Sum subscriptions cost for a period
-// @Description  Считает сумму (в рублях) по активным месяцам в интервале [from,to] с фильтрами
+// @Description  Считает стоимость подписок в интервале [from,to] с фильтрами с учётом периода списания; каждое начисление пересчитывается в валюту currency по курсу на его дату
 // @Tags         subscriptions
 // @Produce      json
-// @Param        from          query     string  true   "Начало периода (MM-YYYY)"
-// @Param        to            query     string  true   "Конец периода (MM-YYYY)"
+// @Param        from          query     string  true   "Начало периода (YYYY-MM-DD или MM-YYYY)"
+// @Param        to            query     string  true   "Конец периода включительно (YYYY-MM-DD или MM-YYYY — месяц целиком)"
 // @Param        user_id       query     string  false  "UUID пользователя"
 // @Param        service_name  query     string  false  "Название сервиса"
-// @Success      200           {object}  map[string]int64  "Сумма, ключ total_rub"
+// @Param        service_match query     string  false  "Сравнение service_name: exact (default), ci — без учёта регистра, prefix, fuzzy — триграммное сходство"
+// @Param        currency      query     string  false  "Валюта результата (ISO 4217, default RUB)"
+// @Param        mode          query     string  false  "charges — фактические списания в периоде (default), amortized — списания размазаны по месяцам, prorated — как amortized, неполные месяцы пропорционально дням"
+// @Param        category      query     string  false  "Категория каталога сервисов"
+// @Param        tag           query     []string  false  "Есть любой из тегов (повтор параметра)"  collectionFormat(multi)
+// @Param        group_by      query     string  false  "Разбивка через запятую: month, service_name, user_id, category, tag (например month,service_name)"
+// @Success      200           {object}  map[string]any    "Сумма: total, currency, mode (и total_rub для RUB); при group_by — buckets"
 // @Failure      400           {object}  map[string]string "Bad request"
+// @Failure      422           {object}  map[string]string "Нет курса валюты"
 // @Failure      500           {object}  map[string]string "Internal error"
 // @Router       /subscriptions/summary [get]
 func (h *Handler) summary(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
-	fromS, toS := q.Get("from"), q.Get("to")
-	if fromS == "" || toS == "" {
-		writeError(w, http.StatusBadRequest, "from/to required MM-YYYY")
-		return
-	}
-	from, err := parseMonthYear(fromS)
+	f, err := parseSummaryFilter(r.URL.Query())
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad from")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	to, err := parseMonthYear(toS)
+	total, buckets, err := h.Repo.Summary(r.Context(), f)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "bad to")
+		if errors.Is(err, storage.ErrNoRate) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		h.Log.Error("summary", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	if to.Before(from) {
-		writeError(w, http.StatusBadRequest, "to before from")
-		return
+	writeJSON(w, http.StatusOK, summaryResponse(f, total, buckets))
+}
+
+// summaryResponse: тело ответа /subscriptions/summary (и отчёта в фоновых задачах)
+func summaryResponse(f storage.SummaryFilter, total float64, buckets []model.SummaryBucket) map[string]any {
+	resp := map[string]any{"total": total, "currency": f.Currency, "mode": f.Mode}
+	if len(f.GroupBy) > 0 {
+		resp["group_by"] = f.GroupBy
+		resp["buckets"] = buckets
+	}
+	if f.Currency == model.BaseCurrency {
+		resp["total_rub"] = total // совместимость со старыми клиентами
 	}
+	return resp
+}
+
+// helpers
 
-	var uid *uuid.UUID
-	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
-		u, err := uuid.Parse(s)
+// parsePayload: общая валидация create/update, ошибки пригодны для ответа клиенту
+func parsePayload(p model.SubscriptionPayload) (*model.Subscription, error) {
+	p.ServiceName = strings.TrimSpace(p.ServiceName)
+	if p.ServiceName == "" || (p.Price != nil && *p.Price < 0) || p.UserID == "" || p.StartDate == "" {
+		return nil, errors.New("missing required fields")
+	}
+	uid, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return nil, errors.New("bad user_id")
+	}
+	currency := model.BaseCurrency
+	if p.Currency != "" {
+		if currency, err = parseCurrency(p.Currency); err != nil {
+			return nil, errors.New("bad currency, use ISO 4217 code")
+		}
+	}
+	period := model.BillingMonthly
+	if p.BillingPeriod != "" {
+		period = model.BillingPeriod(strings.ToLower(strings.TrimSpace(p.BillingPeriod)))
+		if !period.Valid() {
+			return nil, errors.New("bad billing_period, use weekly|monthly|quarterly|yearly")
+		}
+	}
+	interval := 1
+	if p.BillingInterval != 0 {
+		if p.BillingInterval < 0 {
+			return nil, errors.New("bad billing_interval")
+		}
+		interval = p.BillingInterval
+	}
+	start, err := parseDate(p.StartDate, false)
+	if err != nil {
+		return nil, errors.New("bad start_date, use YYYY-MM-DD or MM-YYYY")
+	}
+	var end *time.Time
+	if p.EndDate != nil && *p.EndDate != "" {
+		e, err := parseDate(*p.EndDate, true)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "bad user_id")
-			return
+			return nil, errors.New("bad end_date, use YYYY-MM-DD or MM-YYYY")
+		}
+		end = &e
+		if end.Before(start) {
+			return nil, errors.New("end_date before start_date")
 		}
-		uid = &u
 	}
-	var service *string
-	if s := strings.TrimSpace(q.Get("service_name")); s != "" {
-		service = &s
+
+	price := 0
+	if p.Price != nil {
+		price = *p.Price
+	}
+	var tags []string
+	if p.Tags != nil {
+		if tags, err = normalizeTags(p.Tags); err != nil {
+			return nil, err
+		}
 	}
 
-	total, err := h.Repo.Summary(r.Context(), monthStart(from), monthStart(to), uid, service)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "db error")
-		return
+	return &model.Subscription{
+		ServiceName:     p.ServiceName,
+		Price:           price,
+		Currency:        currency,
+		BillingPeriod:   period,
+		BillingInterval: interval,
+		UserID:          uid,
+		StartDate:       start,
+		EndDate:         end,
+		Tags:            tags,
+	}, nil
+}
+
+// parseCurrency: ISO 4217, три латинские буквы
+func parseCurrency(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 3 {
+		return "", errors.New("bad currency")
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return "", errors.New("bad currency")
+		}
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"total_rub": total})
+	return s, nil
 }
 
-// helpers
+// parseDate: YYYY-MM-DD или MM-YYYY. MM-YYYY означает первый день месяца,
+// а для конца периода (monthEnd) — последний, т.е. месяц целиком.
+func parseDate(s string, monthEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, strings.TrimSpace(s)); err == nil {
+		return t, nil
+	}
+	t, err := parseMonthYear(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if monthEnd {
+		return t.AddDate(0, 1, -1), nil
+	}
+	return t, nil
+}
 
 func parseMonthYear(s string) (time.Time, error) {
 	// expected MM-YYYY
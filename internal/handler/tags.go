@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const maxTagLen = 50
+
+// normalizeTags: обрезает пробелы, приводит к нижнему регистру и убирает дубли
+func normalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := map[string]bool{}
+	for _, t := range raw {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || utf8.RuneCountInString(t) > maxTagLen {
+			return nil, errors.New("bad tag: must be 1-50 characters")
+		}
+		if !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// POST /subscriptions/{id}/tags
+// Add tags
+// @Summary      Add tags
+// @Description  Добавить теги подписке (регистр не учитывается, уже имеющиеся игнорируются)
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "UUID подписки"
+// @Param        payload  body      model.TagsPayload  true  "Теги"
+// @Success      200      {array}   string             "Все теги подписки"
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/tags [post]
+func (h *Handler) addTags(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	var p model.TagsPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if len(p.Tags) == 0 {
+		writeError(w, http.StatusBadRequest, "missing required fields")
+		return
+	}
+	names, err := normalizeTags(p.Tags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tags, ok, err := h.Repo.AddTags(r.Context(), id, names)
+	if err != nil {
+		h.Log.Error("add tags", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// DELETE /subscriptions/{id}/tags/{tag}
+// Remove tag
+// @Summary      Remove tag
+// @Description  Снять тег с подписки
+// @Tags         subscriptions
+// @Param        id   path      string  true  "UUID подписки"
+// @Param        tag  path      string  true  "Тег"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/tags/{tag} [delete]
+func (h *Handler) removeTag(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	tag := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "tag")))
+	ok, err := h.Repo.RemoveTag(r.Context(), id, tag)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /tags
+// List tags
+// @Summary      List tags
+// @Description  Используемые теги с числом подписок, популярные сначала
+// @Tags         subscriptions
+// @Produce      json
+// @Success      200  {array}   model.TagUsage
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /tags [get]
+func (h *Handler) listTags(w http.ResponseWriter, r *http.Request) {
+	items, err := h.Repo.ListTags(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
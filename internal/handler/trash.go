@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GET /subscriptions/trash
+// List deleted subscriptions
+// @Summary      List deleted subscriptions
+// @Description  Корзина: удалённые подписки, ещё не очищенные по сроку хранения (недавно удалённые сначала). Фильтры и пагинация — как у GET /subscriptions
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id        query     []string  false  "UUID пользователей (повтор параметра или через запятую)"  collectionFormat(multi)
+// @Param        service_name   query     []string  false  "Названия сервисов (повтор параметра)"  collectionFormat(multi)
+// @Param        service_match  query     string  false  "Сравнение service_name: exact (default), ci, prefix, fuzzy"
+// @Param        tag            query     []string  false  "Есть любой из тегов (повтор параметра)"  collectionFormat(multi)
+// @Param        sort           query     string  false  "Поле[:asc|desc], как у GET /subscriptions, плюс deleted_at (default deleted_at:desc)"
+// @Param        limit          query     int     false  "Количество записей (default 50, max 200)"
+// @Param        cursor         query     string  false  "Курсор следующей страницы (next_cursor из предыдущего ответа)"
+// @Param        offset         query     int     false  "Смещение от начала списка (игнорируется при cursor)"
+// @Param        include_total  query     bool    false  "Посчитать total — общее количество по фильтру"
+// @Success      200            {object}  model.SubscriptionList
+// @Header       200            {string}  Link  "rel=next / rel=prev / rel=first"
+// @Failure      400            {object}  map[string]string  "Bad request"
+// @Failure      500            {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/trash [get]
+func (h *Handler) trash(w http.ResponseWriter, r *http.Request) {
+	h.writeList(w, r, true)
+}
+
+// POST /subscriptions/{id}/restore
+// Restore deleted subscription
+// @Summary      Restore deleted subscription
+// @Description  Вернуть подписку из корзины вместе с историей цен и тегами
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id   path      string  true  "UUID подписки"
+// @Success      200  {object}  model.Subscription
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Not in trash"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/{id}/restore [post]
+func (h *Handler) restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	s, err := h.Repo.Restore(r.Context(), id)
+	if err != nil {
+		h.Log.Error("restore", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "not in trash")
+		return
+	}
+	h.checkBudgets(r.Context(), s.UserID, id)
+	w.Header().Set("ETag", etag(s))
+	writeJSON(w, http.StatusOK, s)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const maxUpcomingDays = 366
+
+// GET /subscriptions/upcoming?user_id=&days=&currency=&bucket=
+// Upcoming renewals
+// @Summary      Upcoming renewals
+// @Description  Списания активных подписок в ближайшие days дней (начиная с сегодня) по датам от start_date с шагом периода, в хронологическом порядке, и суммы по дням или неделям
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id   query     string  false  "UUID пользователя"
+// @Param        days      query     int     false  "Размер окна в днях (default 30, max 366)"
+// @Param        currency  query     string  false  "Валюта amount и сумм (ISO 4217, default RUB)"
+// @Param        bucket    query     string  false  "Суммы по day (default) или week (с понедельника)"
+// @Success      200       {object}  model.UpcomingRenewals
+// @Failure      400       {object}  map[string]string  "Bad request"
+// @Failure      422       {object}  map[string]string  "Нет курса валюты"
+// @Failure      500       {object}  map[string]string  "Internal error"
+// @Router       /subscriptions/upcoming [get]
+func (h *Handler) upcoming(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var uid *uuid.UUID
+	if s := strings.TrimSpace(q.Get("user_id")); s != "" {
+		u, err := uuid.Parse(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad user_id")
+			return
+		}
+		uid = &u
+	}
+	days := 30
+	if v, err := queryInt(q, "days"); err != nil || (v != nil && (*v < 1 || *v > maxUpcomingDays)) {
+		writeError(w, http.StatusBadRequest, "bad days, use 1-366")
+		return
+	} else if v != nil {
+		days = *v
+	}
+	currency := model.BaseCurrency
+	if s := strings.TrimSpace(q.Get("currency")); s != "" {
+		c, err := parseCurrency(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad currency")
+			return
+		}
+		currency = c
+	}
+	bucket := "day"
+	if s := strings.TrimSpace(q.Get("bucket")); s != "" {
+		if s != "day" && s != "week" {
+			writeError(w, http.StatusBadRequest, "bad bucket, use day|week")
+			return
+		}
+		bucket = s
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, days-1)
+	charges, err := h.Repo.Upcoming(r.Context(), storage.UpcomingFilter{
+		From:     from,
+		To:       to,
+		UserID:   uid,
+		Currency: currency,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRate) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		h.Log.Error("upcoming", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	// charges уже отсортированы по дате, поэтому бакеты идут подряд
+	resp := model.UpcomingRenewals{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Currency: currency,
+		Bucket:   bucket,
+		Charges:  charges,
+		Totals:   []model.UpcomingTotal{},
+	}
+	for _, c := range charges {
+		start := c.Date
+		if bucket == "week" {
+			start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		}
+		key := start.Format("2006-01-02")
+		if n := len(resp.Totals); n == 0 || resp.Totals[n-1].Start != key {
+			resp.Totals = append(resp.Totals, model.UpcomingTotal{Start: key})
+		}
+		resp.Totals[len(resp.Totals)-1].Total += c.Amount
+		resp.Total += c.Amount
+	}
+	for i := range resp.Totals {
+		resp.Totals[i].Total = round2(resp.Totals[i].Total)
+	}
+	resp.Total = round2(resp.Total)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/webhook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// POST /webhooks
+// Create webhook
+// @Summary      Create webhook
+// @Description  Зарегистрировать вебхук. События доставляются POST-ом с подписью X-Webhook-Signature: sha256=HMAC-SHA256(secret, "<X-Webhook-Timestamp>.<body>"). Если secret не задан, он генерируется; секрет возвращается только в этом ответе
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      model.WebhookPayload  true  "Вебхук"
+// @Success      201      {object}  model.Webhook
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      403      {object}  map[string]string  "Admin token not configured"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /webhooks [post]
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var p model.WebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	wh, err := h.parseWebhookPayload(r.Context(), p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if wh.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		wh.Secret = hex.EncodeToString(b)
+	}
+	if err := h.Repo.CreateWebhook(r.Context(), wh); err != nil {
+		h.Log.Error("create webhook", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, wh)
+}
+
+// GET /webhooks
+// List webhooks
+// @Summary      List webhooks
+// @Description  Зарегистрированные вебхуки (без секретов)
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {array}   model.Webhook
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Admin token not configured"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /webhooks [get]
+func (h *Handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	items, err := h.Repo.ListWebhooks(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GET /webhooks/{id}
+// Get webhook
+// @Summary      Get webhook
+// @Description  Вебхук по идентификатору (без секрета)
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "UUID вебхука"
+// @Success      200  {object}  model.Webhook
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Admin token not configured"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /webhooks/{id} [get]
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	wh, err := h.Repo.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if wh == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, wh)
+}
+
+// PUT /webhooks/{id}
+// Update webhook
+// @Summary      Update webhook
+// @Description  Обновить вебхук; пустой secret — секрет не меняется
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                true  "UUID вебхука"
+// @Param        payload  body      model.WebhookPayload  true  "Вебхук"
+// @Success      200      {object}  model.Webhook
+// @Failure      400      {object}  map[string]string  "Bad request"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      403      {object}  map[string]string  "Admin token not configured"
+// @Failure      404      {object}  map[string]string  "Not found"
+// @Failure      500      {object}  map[string]string  "Internal error"
+// @Router       /webhooks/{id} [put]
+func (h *Handler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	var p model.WebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	wh, err := h.parseWebhookPayload(r.Context(), p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ok, err := h.Repo.UpdateWebhook(r.Context(), id, wh)
+	if err != nil {
+		h.Log.Error("update webhook", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	wh.Secret = ""
+	writeJSON(w, http.StatusOK, wh)
+}
+
+// DELETE /webhooks/{id}
+// Delete webhook
+// @Summary      Delete webhook
+// @Description  Удалить вебхук вместе с журналом доставок
+// @Tags         webhooks
+// @Param        id   path      string  true  "UUID вебхука"
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Admin token not configured"
+// @Failure      404  {object}  map[string]string  "Not found"
+// @Failure      500  {object}  map[string]string  "Internal error"
+// @Router       /webhooks/{id} [delete]
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	ok, err := h.Repo.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /webhooks/{id}/deliveries?status=&limit=
+// Webhook delivery log
+// @Summary      Webhook delivery log
+// @Description  Журнал доставок вебхука, новые сначала: статус, число попыток, код и ошибка последней попытки
+// @Tags         webhooks
+// @Produce      json
+// @Param        id      path      string  true   "UUID вебхука"
+// @Param        status  query     string  false  "pending | delivered | dead"
+// @Param        limit   query     int     false  "Количество (default 50, max 200)"
+// @Success      200     {array}   model.WebhookDelivery
+// @Failure      400     {object}  map[string]string  "Bad request"
+// @Failure      401     {object}  map[string]string  "Unauthorized"
+// @Failure      403     {object}  map[string]string  "Admin token not configured"
+// @Failure      500     {object}  map[string]string  "Internal error"
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *Handler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	q := r.URL.Query()
+	status := strings.TrimSpace(q.Get("status"))
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		writeError(w, http.StatusBadRequest, "bad status, use pending|delivered|dead")
+		return
+	}
+	limit := 50
+	if v, err := queryInt(q, "limit"); err == nil && v != nil && *v > 0 && *v <= 200 {
+		limit = *v
+	}
+	items, err := h.Repo.ListDeliveries(r.Context(), id, status, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// POST /webhooks/{id}/deliveries/{delivery_id}/retry
+// Retry webhook delivery
+// @Summary      Retry webhook delivery
+// @Description  Вернуть доставку (например, из dead) в очередь со сброшенным счётчиком попыток
+// @Tags         webhooks
+// @Param        id           path      string  true  "UUID вебхука"
+// @Param        delivery_id  path      int     true  "ID доставки"
+// @Success      202          {string}  string  "Accepted"
+// @Failure      400          {object}  map[string]string  "Bad request"
+// @Failure      401          {object}  map[string]string  "Unauthorized"
+// @Failure      403          {object}  map[string]string  "Admin token not configured"
+// @Failure      404          {object}  map[string]string  "Not found"
+// @Failure      500          {object}  map[string]string  "Internal error"
+// @Router       /webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (h *Handler) retryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad id")
+		return
+	}
+	did, err := strconv.ParseInt(chi.URLParam(r, "delivery_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad delivery_id")
+		return
+	}
+	ok, err := h.Repo.RetryDelivery(r.Context(), id, did)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseWebhookPayload: без AllowPrivateWebhooks адрес должен вести в публичный интернет, иначе вебхуком
+// можно было бы заставить сервер ходить во внутреннюю сеть (SSRF)
+func (h *Handler) parseWebhookPayload(ctx context.Context, p model.WebhookPayload) (*model.Webhook, error) {
+	wh := &model.Webhook{
+		URL:    strings.TrimSpace(p.URL),
+		Events: []string{},
+		Active: true,
+		Secret: p.Secret,
+	}
+	u, err := url.Parse(wh.URL)
+	if wh.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("bad url, use http(s) URL")
+	}
+	if !h.AllowPrivateWebhooks {
+		if err := webhook.CheckTarget(ctx, u.Hostname()); err != nil {
+			return nil, errors.New("bad url, loopback, link-local and private addresses are not allowed")
+		}
+	}
+	for _, e := range p.Events {
+		if !slices.Contains(model.WebhookEvents, e) {
+			return nil, errors.New("bad event " + strconv.Quote(e) + ", use " + strings.Join(model.WebhookEvents, "|"))
+		}
+		if !slices.Contains(wh.Events, e) {
+			wh.Events = append(wh.Events, e)
+		}
+	}
+	if p.Active != nil {
+		wh.Active = *p.Active
+	}
+	return wh, nil
+}
@@ -0,0 +1,226 @@
+// Package jobs выполняет фоновые выгрузки и отчёты из таблицы export_jobs и хранит результат в локальном каталоге.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+)
+
+// progressEvery: как часто сохранять прогресс и продлевать аренду задачи
+const progressEvery = 2 * time.Second
+
+// KindExpire: задача очереди, удаляющая файлы с истёкшим сроком
+const KindExpire = "jobs.expire"
+
+// Progress: сколько подписок обработано и сколько всего (nil — неизвестно)
+type Progress func(processed int64, total *int64)
+
+// Exec пишет результат задачи в w. Текст ошибки виден клиенту в поле error задачи.
+type Exec func(ctx context.Context, job *model.Job, w io.Writer, progress Progress) error
+
+// Worker забирает задачи из export_jobs и выполняет их по одной.
+// Задачи переживают перезапуск: прерванная остановкой возвращается в очередь,
+// а задачу упавшего инстанса забирают заново по истечении аренды.
+type Worker struct {
+	Repo        *storage.Repository
+	Log         *slog.Logger
+	Exec        Exec
+	Dir         string        // каталог файлов; при нескольких инстансах — общий
+	TTL         time.Duration // сколько хранится готовый файл
+	Interval    time.Duration // как часто проверять очередь
+	Lease       time.Duration // аренда running-задачи
+	MaxAttempts int           // столько раз задачу можно забрать заново после потери исполнителя
+}
+
+func New(repo *storage.Repository, lg *slog.Logger, exec Exec, dir string) *Worker {
+	return &Worker{
+		Repo:        repo,
+		Log:         lg,
+		Exec:        exec,
+		Dir:         dir,
+		TTL:         24 * time.Hour,
+		Interval:    2 * time.Second,
+		Lease:       time.Minute,
+		MaxAttempts: 3,
+	}
+}
+
+// Run работает до отмены ctx
+func (wk *Worker) Run(ctx context.Context) {
+	if err := os.MkdirAll(wk.Dir, 0o755); err != nil {
+		wk.Log.Error("jobs dir", slog.String("dir", wk.Dir), slog.Any("err", err))
+		return
+	}
+	t := time.NewTicker(wk.Interval)
+	defer t.Stop()
+	for {
+		wk.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Expire — обработчик KindExpire: помечает задачи с истёкшим сроком expired и удаляет их файлы.
+// Запускается по расписанию очереди.
+func (wk *Worker) Expire(ctx context.Context, _ json.RawMessage) error {
+	names, err := wk.Repo.ExpireJobs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(wk.Dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			wk.Log.Warn("jobs remove", slog.String("file", name), slog.Any("err", err))
+		}
+	}
+	return nil
+}
+
+func (wk *Worker) tick(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := wk.Repo.ClaimJob(ctx, wk.Lease, wk.MaxAttempts)
+		if err != nil {
+			if ctx.Err() == nil {
+				wk.Log.Error("jobs claim", slog.Any("err", err))
+			}
+			return
+		}
+		if job == nil {
+			return
+		}
+		wk.process(ctx, job)
+	}
+}
+
+func (wk *Worker) process(ctx context.Context, job *model.Job) {
+	lg := wk.Log.With(slog.String("job", job.ID.String()), slog.String("kind", job.Kind))
+	// номер попытки в имени: воркер, потерявший аренду, не перезапишет и не удалит файл новой попытки
+	name := job.ID.String() + "." + strconv.Itoa(job.Attempts) + "." + job.Format
+	path := filepath.Join(wk.Dir, name)
+	tmp := path + ".tmp"
+
+	// прогресс пишется в БД не на каждую строку, а раз в progressEvery — заодно продлевает аренду
+	var processed, total atomic.Int64
+	total.Store(-1)
+	save := func(ctx context.Context) error {
+		var tp *int64
+		if t := total.Load(); t >= 0 {
+			tp = &t
+		}
+		return wk.Repo.JobProgress(ctx, job.ID, job.Attempts, processed.Load(), tp, wk.Lease)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost atomic.Bool
+	go func() {
+		t := time.NewTicker(progressEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-t.C:
+				err := save(runCtx)
+				if errors.Is(err, storage.ErrLeaseLost) {
+					// аренда истекла, и задачу уже забрал другой воркер — эту попытку бросаем
+					lost.Store(true)
+					cancel()
+					return
+				}
+				if err != nil && runCtx.Err() == nil {
+					lg.Warn("jobs progress", slog.Any("err", err))
+				}
+			}
+		}
+	}()
+
+	err := wk.write(runCtx, job, tmp, func(n int64, t *int64) {
+		processed.Store(n)
+		if t != nil {
+			total.Store(*t)
+		}
+	})
+	cancel()
+
+	if lost.Load() {
+		os.Remove(tmp)
+		lg.Warn("jobs lease lost")
+		return
+	}
+	// остановка сервера: задачу доделает следующий запуск
+	if ctx.Err() != nil {
+		os.Remove(tmp)
+		if err := wk.Repo.ReleaseJob(context.WithoutCancel(ctx), job.ID, job.Attempts); err != nil {
+			lg.Error("jobs release", slog.Any("err", err))
+		}
+		return
+	}
+	if err != nil {
+		os.Remove(tmp)
+		lg.Error("job failed", slog.Any("err", err))
+		msg := err.Error()
+		if errors.As(err, new(fileError)) {
+			msg = "storage error"
+		}
+		if err := wk.Repo.FailJob(ctx, job.ID, job.Attempts, msg); err != nil {
+			lg.Error("jobs fail", slog.Any("err", err))
+		}
+		return
+	}
+
+	st, err := os.Stat(tmp)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err == nil {
+		err = wk.Repo.FinishJob(ctx, job.ID, job.Attempts, name, st.Size(), processed.Load(), wk.TTL)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		os.Remove(path)
+		if errors.Is(err, storage.ErrLeaseLost) {
+			lg.Warn("jobs lease lost")
+			return
+		}
+		lg.Error("jobs finish", slog.Any("err", err))
+		if err := wk.Repo.FailJob(ctx, job.ID, job.Attempts, "storage error"); err != nil {
+			lg.Error("jobs fail", slog.Any("err", err))
+		}
+		return
+	}
+	lg.Info("job_done", slog.Int64("rows", processed.Load()), slog.Int64("bytes", st.Size()))
+}
+
+// fileError: ошибка файловой системы — подробности только в логе
+type fileError struct{ err error }
+
+func (e fileError) Error() string { return e.err.Error() }
+
+// write выполняет задачу во временный файл
+func (wk *Worker) write(ctx context.Context, job *model.Job, tmp string, progress Progress) error {
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fileError{err}
+	}
+	if err := wk.Exec(ctx, job, f, progress); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fileError{err}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry: одно изменение подписки
+type AuditEntry struct {
+	ID             int64         `json:"id"`
+	SubscriptionID uuid.UUID     `json:"subscription_id"`
+	UserID         uuid.UUID     `json:"user_id"`
+	Action         string        `json:"action"`           // created | updated | deleted | restored
+	Before         *Subscription `json:"before,omitempty"` // пусто для created
+	After          *Subscription `json:"after,omitempty"`  // пусто для deleted
+	Actor          string        `json:"actor"`
+	RequestID      string        `json:"request_id,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// AuditPage: ответ GET /audit
+type AuditPage struct {
+	Items      []AuditEntry `json:"items"`
+	NextBefore *int64       `json:"next_before,omitempty"` // before_id следующей страницы
+}
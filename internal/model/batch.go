@@ -0,0 +1,40 @@
+package model
+
+import "github.com/google/uuid"
+
+// Режимы пакетного запроса
+const (
+	BatchAtomic     = "atomic"      // всё или ничего: при любой ошибке изменения откатываются
+	BatchBestEffort = "best_effort" // успешные операции сохраняются, ошибочные пропускаются
+)
+
+// BatchRequest: тело POST /subscriptions/batch
+type BatchRequest struct {
+	Mode       string           `json:"mode"` // atomic (по умолчанию) | best_effort
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation: одна операция пакета
+type BatchOperation struct {
+	Op      string               `json:"op"`                // create | update | delete
+	ID      string               `json:"id,omitempty"`      // для update и delete
+	Version *int                 `json:"version,omitempty"` // как If-Match: операция выполнится только на этой версии
+	Data    *SubscriptionPayload `json:"data,omitempty"`    // для create и update, как в POST/PUT
+}
+
+// BatchResult: итог одной операции; Status — HTTP-код, который дал бы одиночный запрос
+type BatchResult struct {
+	Index  int        `json:"index"`
+	Op     string     `json:"op"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Status int        `json:"status"` // 424 — операция прошла бы, но пакет atomic откатился
+	Error  string     `json:"error,omitempty"`
+}
+
+// BatchResponse: ответ POST /subscriptions/batch
+type BatchResponse struct {
+	Committed bool          `json:"committed"` // false — в atomic-режиме ничего не сохранено
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
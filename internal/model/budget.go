@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetScope: на что распространяется бюджет
+type BudgetScope string
+
+const (
+	BudgetTotal    BudgetScope = "total"    // все подписки пользователя
+	BudgetService  BudgetScope = "service"  // один сервис (Target — название, без учёта регистра)
+	BudgetCategory BudgetScope = "category" // категория каталога (Target — категория)
+)
+
+func (s BudgetScope) Valid() bool {
+	return s == BudgetTotal || s == BudgetService || s == BudgetCategory
+}
+
+// Budget: месячный лимит расходов пользователя
+type Budget struct {
+	ID        uuid.UUID   `json:"id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Scope     BudgetScope `json:"scope"`
+	Target    *string     `json:"target,omitempty"` // пусто для total
+	Amount    int         `json:"amount"`
+	Currency  string      `json:"currency"`
+	Mode      string      `json:"mode"` // как считать расходы месяца: charges|amortized (см. summary)
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Payload для создания/обновления бюджета
+type BudgetPayload struct {
+	UserID   string  `json:"user_id"`
+	Scope    string  `json:"scope"`  // total (по умолчанию) | service | category
+	Target   *string `json:"target"` // название сервиса или категория
+	Amount   int     `json:"amount"`
+	Currency string  `json:"currency"` // ISO 4217, по умолчанию RUB
+	Mode     string  `json:"mode"`     // charges (по умолчанию) | amortized
+}
+
+// BudgetStatus: расходы текущего месяца относительно бюджета
+type BudgetStatus struct {
+	Budget    Budget  `json:"budget"`
+	Month     string  `json:"month"`     // YYYY-MM
+	Spent     float64 `json:"spent"`     // с начала месяца по сегодня
+	Forecast  float64 `json:"forecast"`  // за весь месяц с учётом предстоящих списаний
+	Remaining float64 `json:"remaining"` // amount - forecast, может быть отрицательным
+	Over      bool    `json:"over"`      // forecast > amount
+}
+
+// BudgetBreach: событие превышения бюджета
+type BudgetBreach struct {
+	ID             int64      `json:"id"`
+	BudgetID       uuid.UUID  `json:"budget_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Month          string     `json:"month"` // YYYY-MM
+	Spent          float64    `json:"spent"` // прогноз за месяц в момент превышения
+	Amount         int        `json:"amount"`
+	Currency       string     `json:"currency"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"` // изменение, после которого бюджет превышен
+	CreatedAt      time.Time  `json:"created_at"`
+}
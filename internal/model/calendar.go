@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeed: ответ на выпуск ссылки на календарь; токен показывается только один раз
+type CalendarFeed struct {
+	UserID uuid.UUID `json:"user_id"`
+	Token  string    `json:"token"`
+	URL    string    `json:"url"`
+}
+
+// CalendarSeries: повторяющиеся списания подписки по одной цене — отрезок истории цен.
+// First и Last — первое и последнее списание отрезка; Last пусто, если отрезок не ограничен.
+type CalendarSeries struct {
+	SubscriptionID  uuid.UUID
+	ServiceName     string
+	Price           int
+	Currency        string
+	BillingPeriod   BillingPeriod
+	BillingInterval int
+	StartDate       time.Time
+	ValidFrom       time.Time
+	First           time.Time
+	Last            *time.Time
+	UpdatedAt       time.Time
+}
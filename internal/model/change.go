@@ -0,0 +1,19 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionChange: запись ленты изменений подписок (пишется триггером на subscriptions)
+type SubscriptionChange struct {
+	ID             int64           `json:"id"`
+	XID            int64           `json:"-"`  // транзакция, записавшая изменение; лента упорядочена по (XID, ID)
+	Op             string          `json:"op"` // created | updated | deleted
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	Data           json.RawMessage `json:"data"` // строка subscriptions после изменения (для deleted — до)
+	CreatedAt      time.Time       `json:"created_at"`
+}
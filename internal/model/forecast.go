@@ -0,0 +1,17 @@
+package model
+
+// ForecastMonth: прогноз расходов за месяц
+type ForecastMonth struct {
+	Month string  `json:"month"` // YYYY-MM
+	Total float64 `json:"total"`
+}
+
+// Forecast: ответ GET /subscriptions/forecast
+type Forecast struct {
+	From     string          `json:"from"` // YYYY-MM-DD, сегодня: прошедшие в этом месяце списания не входят
+	To       string          `json:"to"`   // YYYY-MM-DD, последний день последнего месяца
+	Currency string          `json:"currency"`
+	Mode     string          `json:"mode"`
+	Months   []ForecastMonth `json:"months"` // все месяцы окна, включая нулевые
+	Total    float64         `json:"total"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// ImportError: строка CSV, не прошедшая проверку
+type ImportError struct {
+	Row   int    `json:"row"` // номер строки в файле, заголовок — 1
+	Error string `json:"error"`
+}
+
+// ImportReport: ответ POST /subscriptions/import
+type ImportReport struct {
+	DryRun          bool          `json:"dry_run"`
+	Rows            int           `json:"rows"` // строк данных (пустые не считаются)
+	Valid           int           `json:"valid"`
+	Invalid         int           `json:"invalid"`
+	Imported        int64         `json:"imported"`
+	Errors          []ImportError `json:"errors"`
+	ErrorsTruncated bool          `json:"errors_truncated,omitempty"` // в errors только первые ошибки
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды фоновых задач
+const (
+	JobExport = "export" // выгрузка подписок, как GET /subscriptions/export
+	JobReport = "report" // отчёт о расходах, как GET /subscriptions/summary
+)
+
+// Статусы задачи: queued -> running -> done | failed; done -> expired, когда файл удалён по сроку
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+	JobExpired = "expired"
+)
+
+// Job: фоновая выгрузка или отчёт
+type Job struct {
+	ID          uuid.UUID  `json:"id"`
+	Kind        string     `json:"kind"`
+	Format      string     `json:"format"`
+	Query       string     `json:"query"`
+	Status      string     `json:"status"`
+	Processed   int64      `json:"processed"`          // обработано подписок
+	Total       *int64     `json:"total,omitempty"`    // сколько всего, если известно
+	Progress    *float64   `json:"progress,omitempty"` // processed/total, от 0 до 1
+	Attempts    int        `json:"attempts"`
+	Error       *string    `json:"error,omitempty"`
+	Size        *int64     `json:"size,omitempty"` // размер файла, байт
+	DownloadURL string     `json:"download_url,omitempty"`
+	FileName    string     `json:"-"` // имя файла в каталоге хранилища
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // после этого файл удаляется
+}
+
+// JobPayload: постановка задачи
+type JobPayload struct {
+	Kind   string `json:"kind"`   // export | report
+	Format string `json:"format"` // export: csv | ndjson | json (default json); report: json
+	Query  string `json:"query"`  // параметры, как в query-строке соответствующей ручки, например "from=01-2025&to=12-2025&group_by=month"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PriceChange: цена подписки, действующая начиная с ValidFrom
+type PriceChange struct {
+	Price     int       `json:"price"`
+	ValidFrom time.Time `json:"valid_from"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Payload для добавления изменения цены
+type PriceChangePayload struct {
+	Price     int    `json:"price"`
+	ValidFrom string `json:"valid_from"` // YYYY-MM-DD или MM-YYYY
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ExchangeRate: сколько единиц BaseCurrency стоит 1 единица Currency, начиная с ValidFrom
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	ValidFrom time.Time `json:"valid_from"`
+}
+
+// Payload для загрузки курсов (файл или admin-ручка)
+type ExchangeRatePayload struct {
+	Currency  string  `json:"currency"`   // ISO 4217
+	Rate      float64 `json:"rate"`       // > 0
+	ValidFrom string  `json:"valid_from"` // YYYY-MM-DD
+}
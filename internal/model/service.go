@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service: запись каталога сервисов. Входящие service_name сопоставляются с Name и Aliases без учёта регистра.
+type Service struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     *string   `json:"category,omitempty"`
+	DefaultPrice *int      `json:"default_price,omitempty"`
+	Currency     string    `json:"currency"` // валюта default_price
+	Homepage     *string   `json:"homepage,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Payload для создания/обновления записи каталога
+type ServicePayload struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	Category     *string  `json:"category"`
+	DefaultPrice *int     `json:"default_price"`
+	Currency     string   `json:"currency"` // ISO 4217, по умолчанию RUB
+	Homepage     *string  `json:"homepage"`
+}
@@ -6,22 +6,68 @@ import (
 	"github.com/google/uuid"
 )
 
+// BaseCurrency — валюта, к которой привязаны курсы в exchange_rates
+const BaseCurrency = "RUB"
+
+// BillingPeriod: единица периода списания; вместе с BillingInterval задаёт «раз в N недель/месяцев/...»
+type BillingPeriod string
+
+const (
+	BillingWeekly    BillingPeriod = "weekly"
+	BillingMonthly   BillingPeriod = "monthly"
+	BillingQuarterly BillingPeriod = "quarterly"
+	BillingYearly    BillingPeriod = "yearly"
+)
+
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly:
+		return true
+	}
+	return false
+}
+
 type Subscription struct {
-	ID          uuid.UUID  `json:"id"`
-	ServiceName string     `json:"service_name"`
-	Price       int        `json:"price"`
-	UserID      uuid.UUID  `json:"user_id"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID              uuid.UUID     `json:"id"`
+	ServiceName     string        `json:"service_name"`
+	ServiceID       *uuid.UUID    `json:"service_id,omitempty"` // запись каталога, если название распознано
+	Price           int           `json:"price"`
+	Currency        string        `json:"currency"`
+	BillingPeriod   BillingPeriod `json:"billing_period"`
+	BillingInterval int           `json:"billing_interval"`
+	UserID          uuid.UUID     `json:"user_id"`
+	StartDate       time.Time     `json:"start_date"`
+	EndDate         *time.Time    `json:"end_date,omitempty"`
+	Tags            []string      `json:"tags"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	DeletedAt       *time.Time    `json:"deleted_at,omitempty"` // только у подписок в корзине
+	Version         int           `json:"version"`              // растёт при каждом изменении, он же ETag
 }
 
 // Payload для создания/обновления
 type SubscriptionPayload struct {
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	UserID      string  `json:"user_id"`     // UUID строкой
-	StartDate   string  `json:"start_date"`  // MM-YYYY
-	EndDate     *string `json:"end_date"`    // MM-YYYY или null
+	ServiceName     string   `json:"service_name"`
+	Price           *int     `json:"price"`            // если не задана — default_price из каталога, иначе 0
+	Currency        string   `json:"currency"`         // ISO 4217, по умолчанию RUB (или валюта каталога)
+	BillingPeriod   string   `json:"billing_period"`   // weekly|monthly|quarterly|yearly, по умолчанию monthly
+	BillingInterval int      `json:"billing_interval"` // списание раз в N периодов, по умолчанию 1
+	UserID          string   `json:"user_id"`          // UUID строкой
+	StartDate       string   `json:"start_date"`       // YYYY-MM-DD или MM-YYYY (первый день месяца)
+	EndDate         *string  `json:"end_date"`         // YYYY-MM-DD, MM-YYYY (последний день месяца) или null
+	Tags            []string `json:"tags"`             // при обновлении null — теги не меняются
+}
+
+// SubscriptionList: ответ GET /subscriptions
+type SubscriptionList struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"` // пусто на последней странице
+	Total      *int64         `json:"total,omitempty"`       // только при include_total=true
+}
+
+// ServiceNameSuggestion: вариант автодополнения названия сервиса
+type ServiceNameSuggestion struct {
+	Name          string `json:"name"`
+	Subscriptions int64  `json:"subscriptions"`
+	Users         int64  `json:"users"`
 }
@@ -0,0 +1,13 @@
+package model
+
+import "github.com/google/uuid"
+
+// SummaryBucket: итог по одной комбинации измерений group_by; незадействованные измерения пустые
+type SummaryBucket struct {
+	Month       *string    `json:"month,omitempty"` // YYYY-MM
+	ServiceName *string    `json:"service_name,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Category    *string    `json:"category,omitempty"` // uncategorized — подписки вне каталога или без категории
+	Tag         *string    `json:"tag,omitempty"`      // untagged — подписки без тегов; подписка с несколькими тегами входит в каждый
+	Total       float64    `json:"total"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// TagUsage: тег и число подписок с ним
+type TagUsage struct {
+	Name          string `json:"name"`
+	Subscriptions int64  `json:"subscriptions"`
+}
+
+// Payload для добавления тегов подписке
+type TagsPayload struct {
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UpcomingCharge: одно предстоящее списание по подписке
+type UpcomingCharge struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	UserID         uuid.UUID `json:"user_id"`
+	Date           time.Time `json:"date"`
+	Price          int       `json:"price"`    // цена на дату списания, в валюте подписки
+	Currency       string    `json:"currency"` // валюта подписки
+	Amount         float64   `json:"amount"`   // Price в валюте ответа
+}
+
+// UpcomingTotal: сумма списаний за день или неделю (Start — первый день, для недели — понедельник)
+type UpcomingTotal struct {
+	Start string  `json:"start"` // YYYY-MM-DD
+	Total float64 `json:"total"`
+}
+
+// UpcomingRenewals: ответ GET /subscriptions/upcoming
+type UpcomingRenewals struct {
+	From     string           `json:"from"` // YYYY-MM-DD
+	To       string           `json:"to"`   // YYYY-MM-DD, включительно
+	Currency string           `json:"currency"`
+	Bucket   string           `json:"bucket"` // day|week
+	Charges  []UpcomingCharge `json:"charges"`
+	Totals   []UpcomingTotal  `json:"totals"`
+	Total    float64          `json:"total"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События для вебхуков
+const (
+	EventSubscriptionCreated    = "subscription.created"
+	EventSubscriptionUpdated    = "subscription.updated"
+	EventSubscriptionDeleted    = "subscription.deleted"
+	EventSubscriptionRestored   = "subscription.restored"
+	EventSubscriptionEndingSoon = "subscription.ending_soon"
+	EventBudgetBreached         = "budget.breached"
+)
+
+// WebhookEvents: все события, на которые можно подписаться
+var WebhookEvents = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionRestored,
+	EventSubscriptionEndingSoon,
+	EventBudgetBreached,
+}
+
+// Webhook: адрес, на который POST-ом доставляются события
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // пусто — все события
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // ключ HMAC, возвращается только при создании
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Payload для создания/обновления вебхука
+type WebhookPayload struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"` // по умолчанию true
+	Secret string   `json:"secret"` // при создании: пусто — сгенерировать; при обновлении: пусто — не менять
+}
+
+// WebhookEvent: тело запроса к вебхуку
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"` // subscription.* — подписка (для deleted — до удаления), budget.breached — BudgetBreach
+}
+
+// Статусы доставки
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // попытки исчерпаны
+)
+
+// WebhookDelivery: доставка одного события одному вебхуку
+type WebhookDelivery struct {
+	ID             int64     `json:"id"`
+	WebhookID      uuid.UUID `json:"webhook_id"`
+	EventID        int64     `json:"event_id"`
+	Event          string    `json:"event"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastStatusCode *int      `json:"last_status_code,omitempty"`
+	LastError      *string   `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,267 @@
+// Package queue — очередь фоновых задач в Postgres и расписание к ней.
+// Задачи забираются воркерами всех инстансов через SKIP LOCKED, ошибки повторяются с backoff.
+// Запуски по расписанию ставит только лидер — инстанс, взявший advisory lock, — поэтому периодическая
+// задача выполняется один раз на весь кластер. Если инстанс упал посреди задачи, её повторят после
+// истечения аренды, так что обработчики должны быть идемпотентными.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// leaderLockKey: ключ pg_try_advisory_lock для выбора лидера расписания
+	leaderLockKey int64 = 0x73756273_71756575
+	// KindCleanup: встроенная задача, удаляющая завершённые задачи старше Retention
+	KindCleanup = "queue.cleanup"
+)
+
+// Handler выполняет задачу; ошибка — повтор с backoff, пока не исчерпаны попытки
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type schedule struct {
+	kind string
+	spec Spec
+}
+
+type Queue struct {
+	Repo        *storage.Repository
+	Log         *slog.Logger
+	Workers     int           // задач одновременно на инстанс
+	Interval    time.Duration // как часто проверять очередь и расписание
+	Lease       time.Duration // аренда задачи: за это время обработчик должен закончить
+	MaxAttempts int           // попыток по умолчанию, после — dead
+	BaseBackoff time.Duration // задержка после первой неудачи, дальше удваивается
+	MaxBackoff  time.Duration
+	Retention   time.Duration // сколько хранятся завершённые задачи
+
+	pool      *pgxpool.Pool
+	handlers  map[string]Handler
+	schedules []schedule
+}
+
+func New(pool *pgxpool.Pool, repo *storage.Repository, lg *slog.Logger) *Queue {
+	q := &Queue{
+		Repo:        repo,
+		Log:         lg,
+		Workers:     4,
+		Interval:    time.Second,
+		Lease:       5 * time.Minute,
+		MaxAttempts: 5,
+		BaseBackoff: 10 * time.Second,
+		MaxBackoff:  time.Hour,
+		Retention:   7 * 24 * time.Hour,
+		pool:        pool,
+		handlers:    map[string]Handler{},
+	}
+	q.Schedule(KindCleanup, MustParseSpec("@hourly"), q.cleanup)
+	return q
+}
+
+// Handle регистрирует обработчик; задачи kind берёт только инстанс, где он зарегистрирован. До Run.
+func (q *Queue) Handle(kind string, fn Handler) {
+	q.handlers[kind] = fn
+}
+
+// Schedule: обработчик kind и запуск его по spec. До Run.
+func (q *Queue) Schedule(kind string, spec Spec, fn Handler) {
+	q.Handle(kind, fn)
+	q.schedules = append(q.schedules, schedule{kind: kind, spec: spec})
+}
+
+// Enqueue ставит задачу в очередь на ближайший запуск
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = q.Repo.EnqueueJob(ctx, kind, b, time.Now(), q.MaxAttempts, "")
+	return err
+}
+
+// Run работает до отмены ctx
+func (q *Queue) Run(ctx context.Context) {
+	go q.lead(ctx)
+	kinds := make([]string, 0, len(q.handlers))
+	for k := range q.handlers {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+
+	// слоты воркеров; при остановке ждём, пока задачи вернутся в очередь
+	slots := make(chan struct{}, max(q.Workers, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	t := time.NewTicker(q.Interval)
+	defer t.Stop()
+	for {
+		q.work(ctx, kinds, slots, &wg)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// work забирает задачи, которым пора, по числу свободных слотов: аренда начинается одновременно
+// с выполнением, и задача не ждёт своей очереди, пока её аренда истекает
+func (q *Queue) work(ctx context.Context, kinds []string, slots chan struct{}, wg *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		// слоты занимает только эта горутина, поэтому свободных до запуска меньше не станет
+		free := cap(slots) - len(slots)
+		if free == 0 {
+			return
+		}
+		items, err := q.Repo.ClaimQueueJobs(ctx, kinds, free, q.Lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				q.Log.Warn("queue claim", slog.Any("err", err))
+			}
+			return
+		}
+		for _, it := range items {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(it storage.QueuedJob) {
+				defer func() { <-slots; wg.Done() }()
+				q.run(ctx, it)
+			}(it)
+		}
+		if len(items) < free {
+			return
+		}
+	}
+}
+
+func (q *Queue) run(ctx context.Context, it storage.QueuedJob) {
+	lg := q.Log.With(slog.Int64("job_id", it.ID), slog.String("kind", it.Kind), slog.Int("attempt", it.Attempts))
+	runCtx, cancel := context.WithTimeout(ctx, q.Lease)
+	err := q.handlers[it.Kind](runCtx, it.Payload)
+	cancel()
+	// остановка сервера: задачу доделает другой инстанс или следующий запуск
+	if ctx.Err() != nil {
+		if err := q.Repo.ReleaseQueueJob(context.WithoutCancel(ctx), it.ID, it.Attempts); err != nil {
+			lg.Error("queue release", slog.Any("err", err))
+		}
+		return
+	}
+
+	var (
+		errText *string
+		next    *time.Time
+	)
+	if err != nil {
+		msg := err.Error()
+		errText = &msg
+		if it.Attempts < it.MaxAttempts {
+			t := time.Now().Add(q.backoff(it.Attempts))
+			next = &t
+		}
+		lg.Warn("queue job failed", slog.Bool("retry", next != nil), slog.String("err", msg))
+	}
+	err = q.Repo.FinishQueueJob(ctx, it.ID, it.Attempts, errText, next)
+	if errors.Is(err, storage.ErrLeaseLost) {
+		lg.Warn("queue lease lost")
+	} else if err != nil {
+		lg.Error("queue finish", slog.Any("err", err))
+	}
+}
+
+// backoff: BaseBackoff * 2^(attempt-1), но не больше MaxBackoff
+func (q *Queue) backoff(attempt int) time.Duration {
+	d := q.BaseBackoff
+	for i := 1; i < attempt && d < q.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.MaxBackoff)
+}
+
+// lead пытается стать лидером и, пока им остаётся, ставит задачи по расписанию
+func (q *Queue) lead(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := q.leadOnce(ctx); err != nil && ctx.Err() == nil {
+			q.Log.Warn("queue leader", slog.Any("err", err))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * q.Interval):
+		}
+	}
+}
+
+// leadOnce: advisory lock живёт, пока живёт сессия, поэтому соединение держим отдельно от пула
+// и закрываем при выходе — лидерство сразу переходит к другому инстансу
+func (q *Queue) leadOnce(ctx context.Context) error {
+	conn, err := q.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, leaderLockKey).Scan(&ok); err != nil || !ok {
+		conn.Release()
+		return err
+	}
+	defer conn.Hijack().Close(context.Background())
+	q.Log.Info("queue_leader")
+
+	t := time.NewTicker(q.Interval)
+	defer t.Stop()
+	for {
+		q.schedule(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+		// соединение пропало — lock уже отпущен, и лидером мог стать другой инстанс
+		if _, err := conn.Exec(ctx, `SELECT 1`); err != nil {
+			return err
+		}
+	}
+}
+
+// schedule ставит в очередь запуски, срок которых наступил. Пропущенные (лидера не было) сливаются в один.
+func (q *Queue) schedule(ctx context.Context) {
+	state, err := q.Repo.ListJobSchedules(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			q.Log.Warn("queue schedules", slog.Any("err", err))
+		}
+		return
+	}
+	now := time.Now()
+	for _, s := range q.schedules {
+		st, ok := state[s.kind]
+		if !ok || st.Spec != s.spec.String() {
+			err = q.Repo.SetJobSchedule(ctx, s.kind, s.spec.String(), s.spec.Next(now))
+		} else if !st.NextRunAt.After(now) {
+			var fired bool
+			if fired, err = q.Repo.FireJobSchedule(ctx, s.kind, st.NextRunAt, s.spec.Next(now), q.MaxAttempts); fired {
+				q.Log.Info("queue_scheduled", slog.String("kind", s.kind))
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			q.Log.Warn("queue schedule", slog.String("kind", s.kind), slog.Any("err", err))
+		}
+	}
+}
+
+func (q *Queue) cleanup(ctx context.Context, _ json.RawMessage) error {
+	n, err := q.Repo.PurgeQueueJobs(ctx, time.Now().Add(-q.Retention))
+	if err == nil && n > 0 {
+		q.Log.Info("queue_purged", slog.Int64("count", n))
+	}
+	return err
+}
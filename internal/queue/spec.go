@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec: когда запускать задачу по расписанию
+type Spec interface {
+	// Next: первый запуск строго после t; нулевое время — запусков больше нет
+	Next(t time.Time) time.Time
+	// String: запись расписания; при её смене следующий запуск считается заново
+	String() string
+}
+
+// Every: запуск через равные промежутки
+func Every(d time.Duration) Spec { return every(d) }
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time { return t.Add(time.Duration(e)) }
+func (e every) String() string             { return "@every " + time.Duration(e).String() }
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSpec: cron из 5 полей (минута, час, день месяца, месяц, день недели; *, a-b, списки через запятую, шаг /n),
+// @hourly, @daily, @weekly, @monthly, @yearly или @every <duration>. Время — в часовом поясе сервера.
+func ParseSpec(s string) (Spec, error) {
+	s = strings.TrimSpace(s)
+	if d, ok := strings.CutPrefix(s, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || dur <= 0 {
+			return nil, errors.New("bad @every duration")
+		}
+		return Every(dur), nil
+	}
+	expr := s
+	if m, ok := macros[s]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("cron spec needs 5 fields: minute hour day month weekday")
+	}
+	c := &cron{spec: s}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, errors.New("bad minute: " + err.Error())
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, errors.New("bad hour: " + err.Error())
+	}
+	if c.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, errors.New("bad day of month: " + err.Error())
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, errors.New("bad month: " + err.Error())
+	}
+	if c.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, errors.New("bad day of week: " + err.Error())
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 — тоже воскресенье
+	}
+	c.domAny, c.dowAny, c.hourAny = fields[2] == "*", fields[4] == "*", fields[1] == "*"
+	if c.Next(time.Now()).IsZero() {
+		return nil, errors.New("cron spec never fires")
+	}
+	return c, nil
+}
+
+// MustParseSpec: ParseSpec для расписаний, заданных в коде
+func MustParseSpec(s string) Spec {
+	spec, err := ParseSpec(s)
+	if err != nil {
+		panic("queue: " + s + ": " + err.Error())
+	}
+	return spec
+}
+
+// cron: допустимые значения каждого поля битами
+type cron struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny, hourAny       bool
+}
+
+func (c *cron) String() string { return c.spec }
+
+// Next. Переходы на летнее и зимнее время — как в cron: у расписаний с конкретными часами запуск,
+// попавший в пропущенный час, выполняется сразу после перехода, а в повторённый час — один раз.
+// Расписания с часом * идут по реальному времени.
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		var next time.Time
+		switch {
+		case !has(c.month, int(m)):
+			next = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.day(t):
+			next = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case !has(c.hour, t.Hour()):
+			// не time.Date: несуществующий час он сдвигает назад, и цикл не продвигался бы
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !has(c.minute, t.Minute()), !c.hourAny && repeated(t):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		// полночи или начала месяца может не быть (переход в 00:00) — time.Date вернёт время не позже t
+		if !next.After(t) {
+			next = t.Add(time.Hour)
+		}
+		if next.Sub(t) <= time.Hour && c.gap(t, next) {
+			return next
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// gap: между t и next на часах пропущены часы (переход на летнее время), и на один из них назначен запуск
+func (c *cron) gap(t, next time.Time) bool {
+	if c.hourAny || !has(c.month, int(next.Month())) || !c.day(next) {
+		return false
+	}
+	from, to := t.Hour(), next.Hour()
+	if to < from {
+		to += 24
+	}
+	for h := from + 1; h < to; h++ {
+		if has(c.hour, h%24) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeated: t — второй раз то же время на часах (переход на зимнее время); time.Date выбирает первый
+func repeated(t time.Time) bool {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, t.Location()).Before(t)
+}
+
+// day: как в cron — если ограничены и день месяца, и день недели, достаточно совпасть одному
+func (c *cron) day(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(bits uint64, v int) bool { return bits&(1<<uint(v)) != 0 }
+
+// parseField: "*", "5", "1-5", "*/15", "10-50/10" и их списки через запятую
+func parseField(f string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		rng, stepS, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepS)
+			if err != nil || n <= 0 {
+				return 0, errors.New("bad step " + strconv.Quote(stepS))
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, errors.New("bad value " + strconv.Quote(part))
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, errors.New("bad value " + strconv.Quote(part))
+				}
+			} else if hasStep {
+				to = hi // 5/15 — с 5 до конца с шагом 15
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, errors.New("value out of range " + strconv.Quote(part))
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // America/New_York и America/Havana не зависят от tzdata системы
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		ok   bool
+	}{
+		{"* * * * *", true},
+		{"*/15 * * * *", true},
+		{"5/15 * * * *", true},
+		{"10-50/10 9-18 * * 1-5", true},
+		{"0 0 1,15 * *", true},
+		{"0 0 * * 7", true},
+		{"@hourly", true},
+		{"@yearly", true},
+		{"@every 1m30s", true},
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+		{"@every 0s", false},
+		{"@every soon", false},
+		{"@often", false},
+		// 31 и 30 февраля не бывает
+		{"0 0 31 2 *", false},
+		{"0 0 30 2 *", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSpec(tt.spec)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseSpec(%q) err = %v, want ok = %v", tt.spec, err, tt.ok)
+			}
+			if err == nil && s.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", s.String(), tt.spec)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"strictly after", "0 10 * * *", utc(2026, 10, 17, 10, 0), utc(2026, 10, 18, 10, 0)},
+		{"seconds dropped", "* * * * *", time.Date(2026, 10, 17, 10, 0, 30, 0, time.UTC), utc(2026, 10, 17, 10, 1)},
+		{"step", "*/15 * * * *", utc(2026, 10, 17, 10, 7), utc(2026, 10, 17, 10, 15)},
+		{"step wraps hour", "*/15 * * * *", utc(2026, 10, 17, 10, 45), utc(2026, 10, 17, 11, 0)},
+		{"step from value", "5/15 * * * *", utc(2026, 10, 17, 10, 21), utc(2026, 10, 17, 10, 35)},
+		{"step from value wraps hour", "5/15 * * * *", utc(2026, 10, 17, 10, 50), utc(2026, 10, 17, 11, 5)},
+		{"range with step", "10-50/20 * * * *", utc(2026, 10, 17, 10, 31), utc(2026, 10, 17, 10, 50)},
+		{"list", "0 0 1,15 * *", utc(2026, 10, 2, 0, 0), utc(2026, 10, 15, 0, 0)},
+		// 2026-10-17 — суббота
+		{"sunday as 0", "0 0 * * 0", utc(2026, 10, 17, 12, 0), utc(2026, 10, 18, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(2026, 10, 17, 12, 0), utc(2026, 10, 18, 0, 0)},
+		{"weekdays", "0 9 * * 1-5", utc(2026, 10, 17, 12, 0), utc(2026, 10, 19, 9, 0)},
+		// 2026-12-13 — воскресенье, 2026-12-18 — пятница
+		{"dom or dow: dom matches", "0 0 13 * 5", utc(2026, 12, 12, 0, 0), utc(2026, 12, 13, 0, 0)},
+		{"dom or dow: dow matches", "0 0 13 * 5", utc(2026, 12, 14, 0, 0), utc(2026, 12, 18, 0, 0)},
+		{"dom only", "0 0 13 * *", utc(2026, 12, 14, 0, 0), utc(2027, 1, 13, 0, 0)},
+		{"dow only", "0 0 * * 5", utc(2026, 12, 12, 0, 0), utc(2026, 12, 18, 0, 0)},
+		{"dom or dow: next friday", "0 0 13 * 5", utc(2026, 12, 19, 0, 0), utc(2026, 12, 25, 0, 0)},
+		{"monthly", "@monthly", utc(2026, 1, 31, 10, 0), utc(2026, 2, 1, 0, 0)},
+		{"leap day", "0 0 29 2 *", utc(2026, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"every", "@every 90s", utc(2026, 10, 17, 10, 0), time.Date(2026, 10, 17, 10, 1, 30, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MustParseSpec(tt.spec).Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// на Кубе летнее время начинается в 00:00 — полуночи 8 марта нет
+	havana, err := time.LoadLocation("America/Havana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-03-08 02:00 EST -> 03:00 EDT, 2026-11-01 02:00 EDT -> 01:00 EST
+	edt, est := time.FixedZone("EDT", -4*3600), time.FixedZone("EST", -5*3600)
+	cdt, cst := time.FixedZone("CDT", -4*3600), time.FixedZone("CST", -5*3600)
+	at := func(loc *time.Location, y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{"skipped hour fires after the jump", "30 2 * * *", at(ny, 2026, 3, 7, 12, 0),
+			[]time.Time{at(edt, 2026, 3, 8, 3, 0), at(edt, 2026, 3, 9, 2, 30)}},
+		{"before the jump", "30 1 * * *", at(ny, 2026, 3, 7, 12, 0),
+			[]time.Time{at(est, 2026, 3, 8, 1, 30), at(edt, 2026, 3, 9, 1, 30)}},
+		{"hourly follows real time on spring forward", "0 * * * *", at(ny, 2026, 3, 8, 0, 30),
+			[]time.Time{at(est, 2026, 3, 8, 1, 0), at(edt, 2026, 3, 8, 3, 0), at(edt, 2026, 3, 8, 4, 0)}},
+		{"repeated hour fires once", "30 1 * * *", at(ny, 2026, 10, 31, 12, 0),
+			[]time.Time{at(edt, 2026, 11, 1, 1, 30), at(est, 2026, 11, 2, 1, 30)}},
+		{"after the repeated hour", "30 2 * * *", at(ny, 2026, 10, 31, 12, 0),
+			[]time.Time{at(est, 2026, 11, 1, 2, 30), at(est, 2026, 11, 2, 2, 30)}},
+		{"hourly follows real time on fall back", "0 * * * *", at(ny, 2026, 11, 1, 0, 30),
+			[]time.Time{at(edt, 2026, 11, 1, 1, 0), at(est, 2026, 11, 1, 1, 0), at(est, 2026, 11, 1, 2, 0)}},
+		{"missing midnight", "0 0 * * *", at(havana, 2026, 3, 7, 12, 0),
+			[]time.Time{at(cdt, 2026, 3, 8, 1, 0), at(cdt, 2026, 3, 9, 0, 0)}},
+		{"missing midnight on a fixed day", "30 0 8 3 *", at(havana, 2026, 3, 1, 0, 0),
+			[]time.Time{at(cdt, 2026, 3, 8, 1, 0), at(cst, 2027, 3, 8, 0, 30)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, cur := MustParseSpec(tt.spec), tt.from
+			for i, want := range tt.want {
+				cur = s.Next(cur)
+				if !cur.Equal(want) {
+					t.Fatalf("run %d: Next = %s, want %s", i+1, cur, want.In(cur.Location()))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// AuditMeta: кто и в каком запросе меняет данные; передаётся через context до репозитория
+type AuditMeta struct {
+	Actor     string
+	RequestID string
+}
+
+type auditKey struct{}
+
+func WithAuditMeta(ctx context.Context, m AuditMeta) context.Context {
+	return context.WithValue(ctx, auditKey{}, m)
+}
+
+func auditMetaFrom(ctx context.Context) AuditMeta {
+	m, _ := ctx.Value(auditKey{}).(AuditMeta)
+	if m.Actor == "" {
+		m.Actor = "system"
+	}
+	return m
+}
+
+const auditInsert = `
+	INSERT INTO audit_log (subscription_id, user_id, action, before, after, actor, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+// changed фиксирует изменение подписки в текущей транзакции: запись аудита (до/после) и событие для вебхуков.
+// before — состояние до изменения (nil для created), после читается здесь же (для deleted — не читается).
+func (r *Repository) changed(ctx context.Context, event string, id uuid.UUID, before *model.Subscription) error {
+	var after *model.Subscription
+	if event != model.EventSubscriptionDeleted {
+		var err error
+		if after, err = r.Get(ctx, id); err != nil {
+			return err
+		}
+	}
+	cur := after
+	if cur == nil {
+		cur = before
+	}
+	m := auditMetaFrom(ctx)
+	var reqID *string
+	if m.RequestID != "" {
+		reqID = &m.RequestID
+	}
+	if _, err := r.db.Exec(ctx, auditInsert,
+		id, cur.UserID, strings.TrimPrefix(event, "subscription."), before, after, m.Actor, reqID); err != nil {
+		return err
+	}
+	return r.emit(ctx, event, cur)
+}
+
+type AuditFilter struct {
+	SubscriptionID *uuid.UUID
+	UserID         *uuid.UUID
+	Actor          *string
+	Action         *string
+	RequestID      *string
+	From           *time.Time // created_at >= From
+	To             *time.Time // created_at < To
+	BeforeID       *int64     // keyset: только записи с id < BeforeID
+	Limit          int
+}
+
+// ListAudit: записи аудита, новые сначала; next — BeforeID следующей страницы или nil
+func (r *Repository) ListAudit(ctx context.Context, f AuditFilter) ([]model.AuditEntry, *int64, error) {
+	where := []string{"true"}
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+itoa(len(args))))
+	}
+	if f.SubscriptionID != nil {
+		add("subscription_id = ?", *f.SubscriptionID)
+	}
+	if f.UserID != nil {
+		add("user_id = ?", *f.UserID)
+	}
+	if f.Actor != nil {
+		add("actor = ?", *f.Actor)
+	}
+	if f.Action != nil {
+		add("action = ?", *f.Action)
+	}
+	if f.RequestID != nil {
+		add("request_id = ?", *f.RequestID)
+	}
+	if f.From != nil {
+		add("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < ?", *f.To)
+	}
+	if f.BeforeID != nil {
+		add("id < ?", *f.BeforeID)
+	}
+
+	q := `SELECT id, subscription_id, user_id, action, before, after, actor, COALESCE(request_id, ''), created_at
+		FROM audit_log WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY id DESC LIMIT ` + itoa(f.Limit+1)
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	res := []model.AuditEntry{}
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(&e.ID, &e.SubscriptionID, &e.UserID, &e.Action, &e.Before, &e.After, &e.Actor, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	var next *int64
+	if len(res) > f.Limit {
+		res = res[:f.Limit]
+		next = &res[f.Limit-1].ID
+	}
+	return res, next, nil
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const budgetColumns = `id, user_id, scope, target, amount, currency, mode, created_at, updated_at`
+
+func scanBudget(row pgx.Row, b *model.Budget) error {
+	return row.Scan(&b.ID, &b.UserID, &b.Scope, &b.Target, &b.Amount, &b.Currency, &b.Mode, &b.CreatedAt, &b.UpdatedAt)
+}
+
+// CreateBudget: ErrConflict — у пользователя уже есть бюджет с тем же scope и target
+func (r *Repository) CreateBudget(ctx context.Context, b *model.Budget) error {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO budgets (user_id, scope, target, amount, currency, mode)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`,
+		b.UserID, b.Scope, b.Target, b.Amount, b.Currency, b.Mode)
+	return mapConflict(row.Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt))
+}
+
+func (r *Repository) GetBudget(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	var b model.Budget
+	err := scanBudget(r.db.QueryRow(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE id=$1`, id), &b)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBudgets: бюджеты пользователя (или всех, если userID nil)
+func (r *Repository) ListBudgets(ctx context.Context, userID *uuid.UUID) ([]model.Budget, error) {
+	q := `SELECT ` + budgetColumns + ` FROM budgets`
+	args := []any{}
+	if userID != nil {
+		q += " WHERE user_id=$1"
+		args = append(args, *userID)
+	}
+	q += " ORDER BY user_id, scope DESC, lower(target)"
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Budget{}
+	for rows.Next() {
+		var b model.Budget
+		if err := scanBudget(rows, &b); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repository) UpdateBudget(ctx context.Context, id uuid.UUID, b *model.Budget) (bool, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE budgets
+		SET user_id=$1, scope=$2, target=$3, amount=$4, currency=$5, mode=$6, updated_at=now()
+		WHERE id=$7
+		RETURNING id, created_at, updated_at`,
+		b.UserID, b.Scope, b.Target, b.Amount, b.Currency, b.Mode, id)
+	err := row.Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, mapConflict(err)
+	}
+	return true, nil
+}
+
+func (r *Repository) DeleteBudget(ctx context.Context, id uuid.UUID) (bool, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM budgets WHERE id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// AddBreach: записывает превышение бюджета за месяц и событие budget.breached; false — за этот месяц оно уже записано
+func (r *Repository) AddBreach(ctx context.Context, b *model.BudgetBreach, month time.Time) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		err := tx.db.QueryRow(ctx, `
+			INSERT INTO budget_breaches (budget_id, user_id, month, spent, amount, currency, subscription_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (budget_id, month) DO NOTHING
+			RETURNING id, created_at`,
+			b.BudgetID, b.UserID, month, b.Spent, b.Amount, b.Currency, b.SubscriptionID).Scan(&b.ID, &b.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ok = true
+		b.Month = month.Format("2006-01")
+		return tx.emit(ctx, model.EventBudgetBreached, b)
+	})
+	return ok, err
+}
+
+// ListBreaches: превышения бюджетов, новые сначала
+func (r *Repository) ListBreaches(ctx context.Context, userID *uuid.UUID, limit int) ([]model.BudgetBreach, error) {
+	q := `SELECT id, budget_id, user_id, to_char(month, 'YYYY-MM'), spent::float8, amount, currency, subscription_id, created_at
+		FROM budget_breaches`
+	args := []any{}
+	if userID != nil {
+		q += " WHERE user_id=$1"
+		args = append(args, *userID)
+	}
+	q += " ORDER BY created_at DESC, id DESC LIMIT " + itoa(limit)
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.BudgetBreach{}
+	for rows.Next() {
+		var b model.BudgetBreach
+		if err := rows.Scan(&b.ID, &b.BudgetID, &b.UserID, &b.Month, &b.Spent, &b.Amount, &b.Currency, &b.SubscriptionID, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	return res, rows.Err()
+}
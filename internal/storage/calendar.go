@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SetCalendarToken: сохраняет хэш токена ленты пользователя, старый токен перестаёт работать
+func (r *Repository) SetCalendarToken(ctx context.Context, userID uuid.UUID, hash string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO calendar_feeds (user_id, token_hash) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = now()`, userID, hash)
+	return err
+}
+
+// DeleteCalendarToken: false — ленты не было
+func (r *Repository) DeleteCalendarToken(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM calendar_feeds WHERE user_id=$1`, userID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// CalendarUser: владелец ленты по хэшу токена; nil — токен неизвестен
+func (r *Repository) CalendarUser(ctx context.Context, hash string) (*uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT user_id FROM calendar_feeds WHERE token_hash=$1`, hash).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// CalendarSeries: списания подписок пользователя, разбитые по отрезкам истории цен.
+// Первый отрезок начинается с start_date (price_at до первой записи берёт самую раннюю цену),
+// отрезки без списаний пропускаются.
+func (r *Repository) CalendarSeries(ctx context.Context, userID uuid.UUID) ([]model.CalendarSeries, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH seg AS (
+		 SELECT s.id, s.service_name, p.price, s.currency, s.billing_period, s.billing_interval, s.start_date, s.updated_at,
+		  CASE WHEN LAG(p.valid_from) OVER w IS NULL THEN s.start_date ELSE GREATEST(p.valid_from, s.start_date) END AS lo,
+		  CASE WHEN s.end_date IS NULL THEN LEAD(p.valid_from) OVER w - 1
+		   ELSE LEAST(LEAD(p.valid_from) OVER w - 1, s.end_date) END AS hi
+		 FROM subscriptions s
+		 JOIN subscription_prices p ON p.subscription_id = s.id
+		 WHERE s.user_id = $1 AND s.deleted_at IS NULL
+		 WINDOW w AS (PARTITION BY s.id ORDER BY p.valid_from)
+		)
+		SELECT id, service_name, price, currency, billing_period, billing_interval, start_date, lo, f.first, l.last, updated_at
+		FROM seg
+		CROSS JOIN LATERAL (
+		 SELECT MIN(d) AS first
+		 FROM charge_dates(start_date, billing_period, billing_interval, lo,
+		  COALESCE(hi, (lo + billing_step(billing_period, billing_interval))::date)) d
+		) f
+		CROSS JOIN LATERAL (
+		 SELECT MAX(d) AS last
+		 FROM charge_dates(start_date, billing_period, billing_interval, lo, hi) d
+		 WHERE hi IS NOT NULL
+		) l
+		WHERE f.first IS NOT NULL AND (hi IS NULL OR l.last IS NOT NULL)
+		ORDER BY start_date, id, lo`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.CalendarSeries{}
+	for rows.Next() {
+		var c model.CalendarSeries
+		if err := rows.Scan(&c.SubscriptionID, &c.ServiceName, &c.Price, &c.Currency, &c.BillingPeriod, &c.BillingInterval,
+			&c.StartDate, &c.ValidFrom, &c.First, &c.Last, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
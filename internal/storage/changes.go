@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ChangePos: позиция в ленте изменений. Лента упорядочена по (xid, id), а не по id:
+// id выдаётся при INSERT, но строка видна только после COMMIT, и транзакции фиксируются не по порядку id.
+type ChangePos struct {
+	XID int64
+	ID  int64
+}
+
+// PosOf: позиция изменения
+func PosOf(c model.SubscriptionChange) ChangePos {
+	return ChangePos{XID: c.XID, ID: c.ID}
+}
+
+// Before: p раньше o
+func (p ChangePos) Before(o ChangePos) bool {
+	return p.XID < o.XID || (p.XID == o.XID && p.ID < o.ID)
+}
+
+// ChangesSince: изменения после позиции after по порядку ленты, опционально только одного пользователя.
+// Возвращаются только изменения транзакций старше pg_snapshot_xmin — все они уже завершены,
+// поэтому позже не появится изменение с позицией меньше последней возвращённой.
+func (r *Repository) ChangesSince(ctx context.Context, after ChangePos, userID *uuid.UUID, limit int) ([]model.SubscriptionChange, error) {
+	q := `SELECT id, xid::text::bigint, op, subscription_id, user_id, data, created_at FROM subscription_changes
+		WHERE (xid, id) > ($1::bigint::text::xid8, $2) AND xid < pg_snapshot_xmin(pg_current_snapshot())`
+	args := []any{after.XID, after.ID}
+	if userID != nil {
+		q += " AND user_id=$3"
+		args = append(args, *userID)
+	}
+	q += " ORDER BY xid, id LIMIT " + itoa(limit)
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.SubscriptionChange
+	for rows.Next() {
+		var c model.SubscriptionChange
+		if err := rows.Scan(&c.ID, &c.XID, &c.Op, &c.SubscriptionID, &c.UserID, &c.Data, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// ChangeHorizon: позиция, с которой начинаются ещё не отданные ChangesSince изменения
+func (r *Repository) ChangeHorizon(ctx context.Context) (ChangePos, error) {
+	var p ChangePos
+	err := r.db.QueryRow(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`).Scan(&p.XID)
+	return p, err
+}
+
+// ChangePosByID: позиция изменения по id (Last-Event-ID). Изменения уже нет в ленте (очищено или не было) —
+// нулевая позиция, т.е. вся сохранённая лента.
+func (r *Repository) ChangePosByID(ctx context.Context, id int64) (ChangePos, error) {
+	p := ChangePos{ID: id}
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE((SELECT xid::text::bigint FROM subscription_changes WHERE id=$1), 0)`, id).Scan(&p.XID)
+	if p.XID == 0 {
+		p.ID = 0
+	}
+	return p, err
+}
+
+// PurgeChanges удаляет изменения старше before; продолжить поток с более раннего Last-Event-ID уже нельзя
+func (r *Repository) PurgeChanges(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM subscription_changes WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Транзакция A получает id изменения раньше B, но фиксируется позже. Пока A не завершена,
+// изменение B отдавать нельзя: иначе курсор уйдёт за него, и изменение A потеряется.
+func TestChangesSinceOutOfOrderCommit(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewRepository(pool)
+	uid := uuid.New()
+
+	pos, err := repo.ChangeHorizon(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := `INSERT INTO subscriptions (service_name, price, user_id, start_date) VALUES ($1, 100, $2, CURRENT_DATE)`
+
+	txA, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txA.Rollback(ctx)
+	if _, err := txA.Exec(ctx, insert, "A", uid); err != nil {
+		t.Fatal(err)
+	}
+	txB, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txB.Rollback(ctx)
+	if _, err := txB.Exec(ctx, insert, "B", uid); err != nil {
+		t.Fatal(err)
+	}
+	if err := txB.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// читаем так же, как хаб: двигаем позицию по отданным изменениям
+	var got []string
+	read := func() {
+		items, err := repo.ChangesSince(ctx, pos, &uid, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range items {
+			if !pos.Before(PosOf(c)) {
+				t.Fatalf("change %d returned out of order", c.ID)
+			}
+			pos = PosOf(c)
+			var row struct {
+				ServiceName string `json:"service_name"`
+			}
+			if err := json.Unmarshal(c.Data, &row); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, row.ServiceName)
+		}
+	}
+
+	read()
+	if len(got) != 0 {
+		t.Fatalf("B returned while A is in flight: %v", got)
+	}
+	if err := txA.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	read()
+	if len(got) != 2 || got[0] != "A" || got[1] != "B" {
+		t.Fatalf("got %v, want [A B]", got)
+	}
+}
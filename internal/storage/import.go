@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// importChunk: сколько импортированных подписок за раз дочитывается для аудита и событий
+const importChunk = 500
+
+var importColumns = []string{"id", "service_name", "service_id", "price", "currency", "billing_period", "billing_interval",
+	"user_id", "start_date", "end_date", "tags"}
+
+// ImportSubscriptions вставляет подписки из next одной транзакцией. Строки идут через COPY во временную таблицу,
+// оттуда одним INSERT ... SELECT — в subscriptions, историю цен и теги; аудит и события subscription.created
+// пишутся пачками по importChunk. next возвращает nil в конце; его ошибка откатывает весь импорт и возвращается как есть.
+// next вызывается из отдельной горутины, но не параллельно самому себе и до возврата из ImportSubscriptions;
+// ID отданной подписки заполняется сразу.
+func (r *Repository) ImportSubscriptions(ctx context.Context, next func() (*model.Subscription, error)) (int64, error) {
+	var n int64
+	err := r.inTx(ctx, func(tx *Repository) error {
+		if _, err := tx.db.Exec(ctx, `
+			CREATE TEMP TABLE import_rows (
+			 id UUID PRIMARY KEY, service_name TEXT, service_id UUID, price INTEGER, currency TEXT, billing_period TEXT,
+			 billing_interval INTEGER, user_id UUID, start_date DATE, end_date DATE, tags TEXT[]
+			) ON COMMIT DROP`); err != nil {
+			return err
+		}
+		src := &importSource{next: next}
+		var err error
+		n, err = tx.db.CopyFrom(ctx, pgx.Identifier{"import_rows"}, importColumns, src)
+		if src.err != nil {
+			return src.err // иначе до нас дошла бы только ошибка COPY, которую вызвал src
+		}
+		if err != nil || n == 0 {
+			return err
+		}
+
+		cols := strings.Join(importColumns[:len(importColumns)-1], ", ")
+		if _, err := tx.db.Exec(ctx, `INSERT INTO subscriptions (`+cols+`) SELECT `+cols+` FROM import_rows`); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `
+			INSERT INTO subscription_prices (subscription_id, price, valid_from)
+			SELECT id, price, start_date FROM import_rows`); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `
+			INSERT INTO tags (name) SELECT DISTINCT unnest(tags) FROM import_rows
+			ON CONFLICT (name) DO NOTHING`); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `
+			INSERT INTO subscription_tags (subscription_id, tag_id)
+			SELECT i.id, t.id FROM import_rows i
+			CROSS JOIN LATERAL unnest(i.tags) AS n(name)
+			JOIN tags t ON t.name = n.name
+			ON CONFLICT DO NOTHING`); err != nil {
+			return err
+		}
+		return tx.importCreated(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// importCreated: запись аудита и событие subscription.created для каждой подписки из import_rows
+func (r *Repository) importCreated(ctx context.Context) error {
+	m := auditMetaFrom(ctx)
+	var reqID *string
+	if m.RequestID != "" {
+		reqID = &m.RequestID
+	}
+	after := uuid.Nil
+	for {
+		rows, err := r.db.Query(ctx, `
+			SELECT `+subscriptionColumns+` FROM subscriptions
+			WHERE id IN (SELECT id FROM import_rows WHERE id > $1 ORDER BY id LIMIT $2)
+			ORDER BY id`, after, importChunk)
+		if err != nil {
+			return err
+		}
+		subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Subscription, error) {
+			var s model.Subscription
+			err := scanSubscription(row, &s)
+			return s, err
+		})
+		if err != nil || len(subs) == 0 {
+			return err
+		}
+
+		b := &pgx.Batch{}
+		for _, s := range subs {
+			payload, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			b.Queue(auditInsert, s.ID, s.UserID, "created", nil, payload, m.Actor, reqID)
+			b.Queue(outboxInsert, model.EventSubscriptionCreated, payload)
+		}
+		if err := r.db.SendBatch(ctx, b).Close(); err != nil {
+			return err
+		}
+		after = subs[len(subs)-1].ID
+	}
+}
+
+// importSource отдаёт подписки из next в CopyFrom
+type importSource struct {
+	next func() (*model.Subscription, error)
+	row  []any
+	err  error
+}
+
+func (s *importSource) Next() bool {
+	sub, err := s.next()
+	if err != nil {
+		s.err = err
+		return false
+	}
+	if sub == nil {
+		return false
+	}
+	tags := sub.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	sub.ID = uuid.New()
+	s.row = []any{sub.ID, sub.ServiceName, sub.ServiceID, sub.Price, sub.Currency, string(sub.BillingPeriod), sub.BillingInterval,
+		sub.UserID, sub.StartDate, sub.EndDate, tags}
+	return true
+}
+
+func (s *importSource) Values() ([]any, error) { return s.row, nil }
+
+func (s *importSource) Err() error { return s.err }
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrLeaseLost: аренду задачи забрал другой воркер (эта истекла), результат этой попытки не записан
+var ErrLeaseLost = errors.New("job lease lost")
+
+const jobColumns = `id, kind, format, query, status, processed, total, attempts, error, COALESCE(file_name, ''), size,
+	created_at, started_at, finished_at, expires_at`
+
+func scanJob(row pgx.Row, j *model.Job) error {
+	return row.Scan(&j.ID, &j.Kind, &j.Format, &j.Query, &j.Status, &j.Processed, &j.Total, &j.Attempts, &j.Error, &j.FileName, &j.Size,
+		&j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt)
+}
+
+func (r *Repository) CreateJob(ctx context.Context, j *model.Job) error {
+	return scanJob(r.db.QueryRow(ctx, `
+		INSERT INTO export_jobs (kind, format, query) VALUES ($1, $2, $3)
+		RETURNING `+jobColumns, j.Kind, j.Format, j.Query), j)
+}
+
+func (r *Repository) GetJob(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	var j model.Job
+	err := scanJob(r.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE id=$1`, id), &j)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// ClaimJob забирает самую старую задачу из очереди (или running с истёкшей арендой — её инстанс упал)
+// и продлевает аренду на lease. Задачи, потерявшие исполнителя maxAttempts раз, помечаются failed.
+// nil — задач нет; SKIP LOCKED позволяет запускать на нескольких инстансах.
+func (r *Repository) ClaimJob(ctx context.Context, lease time.Duration, maxAttempts int) (*model.Job, error) {
+	if _, err := r.db.Exec(ctx, `
+		UPDATE export_jobs
+		SET status='failed', error='worker lost', finished_at=now(), locked_until=NULL
+		WHERE status='running' AND locked_until < now() AND attempts >= $1`, maxAttempts); err != nil {
+		return nil, err
+	}
+
+	var j model.Job
+	err := scanJob(r.db.QueryRow(ctx, `
+		UPDATE export_jobs
+		SET status='running', attempts = attempts + 1, processed=0, total=NULL,
+		    started_at=now(), locked_until = now() + $1::interval
+		WHERE id = (
+		   SELECT id FROM export_jobs
+		   WHERE status='queued' OR (status='running' AND locked_until < now())
+		   ORDER BY created_at
+		   LIMIT 1
+		   FOR UPDATE SKIP LOCKED)
+		RETURNING `+jobColumns, lease), &j)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// JobProgress сохраняет прогресс running-задачи и продлевает аренду.
+// attempt — номер попытки из ClaimJob: по нему отсекаются записи воркера, потерявшего аренду.
+func (r *Repository) JobProgress(ctx context.Context, id uuid.UUID, attempt int, processed int64, total *int64, lease time.Duration) error {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE export_jobs SET processed=$3, total=$4, locked_until = now() + $5::interval
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt, processed, total, lease)
+	return leaseResult(ct, err)
+}
+
+// FinishJob: файл готов и хранится ttl
+func (r *Repository) FinishJob(ctx context.Context, id uuid.UUID, attempt int, fileName string, size, processed int64, ttl time.Duration) error {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE export_jobs
+		SET status='done', file_name=$3, size=$4, processed=$5, finished_at=now(), expires_at = now() + $6::interval, locked_until=NULL
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt, fileName, size, processed, ttl)
+	return leaseResult(ct, err)
+}
+
+func (r *Repository) FailJob(ctx context.Context, id uuid.UUID, attempt int, msg string) error {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE export_jobs SET status='failed', error=$3, finished_at=now(), locked_until=NULL
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt, msg)
+	return leaseResult(ct, err)
+}
+
+// ReleaseJob возвращает прерванную остановкой сервера задачу в очередь; попытка не засчитывается
+func (r *Repository) ReleaseJob(ctx context.Context, id uuid.UUID, attempt int) error {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE export_jobs SET status='queued', attempts = GREATEST(attempts - 1, 0), processed=0, total=NULL, locked_until=NULL
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt)
+	return leaseResult(ct, err)
+}
+
+// leaseResult: ни одной строки — задача уже не наша
+func leaseResult(ct pgconn.CommandTag, err error) error {
+	if err == nil && ct.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+// ExpireJobs переводит готовые задачи с истёкшим сроком в expired и возвращает имена их файлов для удаления
+func (r *Repository) ExpireJobs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE export_jobs SET status='expired'
+		WHERE id IN (
+		   SELECT id FROM export_jobs
+		   WHERE status='done' AND expires_at <= now()
+		   FOR UPDATE SKIP LOCKED)
+		RETURNING COALESCE(file_name, '')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name != "" {
+			res = append(res, name)
+		}
+	}
+	return res, rows.Err()
+}
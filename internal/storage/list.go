@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+type ListFilter struct {
+	UserIDs      []uuid.UUID  // любой из
+	ServiceNames []string     // любое из
+	ServiceMatch ServiceMatch // как сравнивать ServiceNames, по умолчанию MatchExact
+	Categories   []string     // категория каталога, любая из, без учёта регистра
+	Tags         []string     // есть любой из тегов
+	PriceMin     *int
+	PriceMax     *int
+	ActiveAt     *time.Time // подписка активна в этот день
+	StartFrom    *time.Time // start_date >= StartFrom
+	StartTo      *time.Time // start_date <= StartTo
+	EndFrom      *time.Time // end_date >= EndFrom
+	EndTo        *time.Time // end_date <= EndTo
+	HasEndDate   *bool
+	Deleted      bool   // только подписки в корзине вместо обычных
+	Sort         string // ключ listSorts, по умолчанию created_at (для корзины — deleted_at)
+	Desc         bool   // направление Sort; при пустом Sort всегда по убыванию
+	Limit        int
+	Offset       int    // режим совместимости, игнорируется при заданном Cursor
+	Cursor       string // непрозрачный курсор из предыдущей страницы
+}
+
+// ErrBadCursor: курсор не разобрался (испорчен, от другой сортировки или версии API)
+var ErrBadCursor = errors.New("bad cursor")
+
+// listSort: колонка сортировки. expr попадает в SQL как есть, поэтому сортировать можно только по listSorts.
+// NULL в end_date сортируется как бесконечность (в deleted_at — как минус бесконечность), чтобы keyset-сравнение было корректным.
+type listSort struct {
+	expr  string
+	cast  string                            // тип значения из курсора
+	value func(s model.Subscription) string // значение expr для курсора
+}
+
+var listSorts = map[string]listSort{
+	"created_at": {"created_at", "timestamptz", func(s model.Subscription) string { return s.CreatedAt.Format(time.RFC3339Nano) }},
+	"updated_at": {"updated_at", "timestamptz", func(s model.Subscription) string { return s.UpdatedAt.Format(time.RFC3339Nano) }},
+	"start_date": {"start_date", "date", func(s model.Subscription) string { return s.StartDate.Format(time.DateOnly) }},
+	"end_date": {"COALESCE(end_date, 'infinity'::date)", "date", func(s model.Subscription) string {
+		if s.EndDate == nil {
+			return "infinity"
+		}
+		return s.EndDate.Format(time.DateOnly)
+	}},
+	"deleted_at": {"COALESCE(deleted_at, '-infinity'::timestamptz)", "timestamptz", func(s model.Subscription) string {
+		if s.DeletedAt == nil {
+			return "-infinity"
+		}
+		return s.DeletedAt.Format(time.RFC3339Nano)
+	}},
+	"price":        {"price", "integer", func(s model.Subscription) string { return strconv.Itoa(s.Price) }},
+	"service_name": {"service_name", "text", func(s model.Subscription) string { return s.ServiceName }},
+}
+
+func ValidSort(s string) bool { _, ok := listSorts[s]; return ok }
+
+// listCursor: позиция последней отданной строки в порядке (sort, id)
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeCursor(c listCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrBadCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil {
+		return c, ErrBadCursor
+	}
+	return c, nil
+}
+
+// listWhere: условия ListFilter (без курсора и пагинации) и их аргументы.
+// Все значения идут параметрами, в текст запроса попадают только имена колонок.
+func listWhere(f ListFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+itoa(len(args))))
+	}
+
+	if f.Deleted {
+		conds = append(conds, "deleted_at IS NOT NULL")
+	} else {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if len(f.UserIDs) > 0 {
+		ids := make([]string, len(f.UserIDs))
+		for i, id := range f.UserIDs {
+			ids[i] = id.String()
+		}
+		add("user_id = ANY(?::uuid[])", ids)
+	}
+	if len(f.ServiceNames) > 0 {
+		add(serviceNameCond("service_name", f.ServiceMatch, f.ServiceNames))
+	}
+	if len(f.Categories) > 0 {
+		add("service_id IN (SELECT id FROM services WHERE lower(category) = ANY(?::text[]))", lowerAll(f.Categories, ""))
+	}
+	if len(f.Tags) > 0 {
+		add("id IN (SELECT st.subscription_id FROM subscription_tags st JOIN tags t ON t.id = st.tag_id WHERE t.name = ANY(?::text[]))", f.Tags)
+	}
+	if f.PriceMin != nil {
+		add("price >= ?", *f.PriceMin)
+	}
+	if f.PriceMax != nil {
+		add("price <= ?", *f.PriceMax)
+	}
+	if f.ActiveAt != nil {
+		add("start_date <= ?::date AND COALESCE(end_date, 'infinity'::date) >= ?::date", *f.ActiveAt)
+	}
+	if f.StartFrom != nil {
+		add("start_date >= ?::date", *f.StartFrom)
+	}
+	if f.StartTo != nil {
+		add("start_date <= ?::date", *f.StartTo)
+	}
+	if f.EndFrom != nil {
+		add("end_date >= ?::date", *f.EndFrom)
+	}
+	if f.EndTo != nil {
+		add("end_date <= ?::date", *f.EndTo)
+	}
+	if f.HasEndDate != nil {
+		if *f.HasEndDate {
+			conds = append(conds, "end_date IS NOT NULL")
+		} else {
+			conds = append(conds, "end_date IS NULL")
+		}
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// List: страница подписок в порядке f.Sort (по умолчанию новые сначала). Второе значение — курсор
+// следующей страницы (пустой, если это последняя); он годится и после страницы, полученной через Offset.
+func (r *Repository) List(ctx context.Context, f ListFilter) ([]model.Subscription, string, error) {
+	if f.Sort == "" {
+		f.Sort, f.Desc = "created_at", true
+		if f.Deleted {
+			f.Sort = "deleted_at"
+		}
+	}
+	sort, ok := listSorts[f.Sort]
+	if !ok {
+		return nil, "", errors.New("unknown sort " + f.Sort)
+	}
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	where, args := listWhere(f)
+	q := `SELECT ` + subscriptionColumns + ` FROM subscriptions` + where
+
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil || c.Sort != f.Sort || c.Desc != f.Desc {
+			return nil, "", ErrBadCursor
+		}
+		q += " AND (" + sort.expr + ", id) " + cmp + " ($" + itoa(len(args)+1) + "::text::" + sort.cast + ", $" + itoa(len(args)+2) + ")"
+		args = append(args, c.Value, c.ID)
+		f.Offset = 0
+	}
+	q += " ORDER BY " + sort.expr + " " + dir + ", id " + dir
+	if f.Limit > 0 {
+		// на одну строку больше, чтобы понять, есть ли следующая страница
+		q += " LIMIT $" + itoa(len(args)+1)
+		args = append(args, f.Limit+1)
+	}
+	if f.Offset > 0 {
+		q += " OFFSET $" + itoa(len(args)+1)
+		args = append(args, f.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	res := []model.Subscription{}
+	for rows.Next() {
+		var s model.Subscription
+		if err := scanSubscription(rows, &s); err != nil {
+			return nil, "", err
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if f.Limit > 0 && len(res) > f.Limit {
+		res = res[:f.Limit]
+		last := res[len(res)-1]
+		next = encodeCursor(listCursor{Sort: f.Sort, Desc: f.Desc, Value: sort.value(last), ID: last.ID})
+	}
+	return res, next, nil
+}
+
+// Count: сколько всего подписок подходит под фильтр (курсор и пагинация не учитываются)
+func (r *Repository) Count(ctx context.Context, f ListFilter) (int64, error) {
+	where, args := listWhere(f)
+	var n int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM subscriptions`+where, args...).Scan(&n)
+	return n, err
+}
+
+// exportFetch: сколько строк за раз забирается из курсора выгрузки
+const exportFetch = 500
+
+// Export передаёт в fn все подписки под фильтром f в порядке f.Sort, как List, но без пагинации (Limit, Offset и Cursor
+// не учитываются). Строки читаются серверным курсором по exportFetch, поэтому память не зависит от объёма выгрузки.
+// Ошибка fn прерывает выгрузку и возвращается как есть.
+func (r *Repository) Export(ctx context.Context, f ListFilter, fn func(s *model.Subscription) error) error {
+	if f.Sort == "" {
+		f.Sort, f.Desc = "created_at", true
+	}
+	sort, ok := listSorts[f.Sort]
+	if !ok {
+		return errors.New("unknown sort " + f.Sort)
+	}
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	where, args := listWhere(f)
+	q := `SELECT ` + subscriptionColumns + ` FROM subscriptions` + where + ` ORDER BY ` + sort.expr + " " + dir + ", id " + dir
+
+	return r.inTx(ctx, func(tx *Repository) error {
+		if _, err := tx.db.Exec(ctx, `DECLARE export_cur NO SCROLL CURSOR FOR `+q, args...); err != nil {
+			return err
+		}
+		for {
+			rows, err := tx.db.Query(ctx, `FETCH `+itoa(exportFetch)+` FROM export_cur`)
+			if err != nil {
+				return err
+			}
+			n := 0
+			for rows.Next() {
+				var s model.Subscription
+				if err := scanSubscription(rows, &s); err != nil {
+					rows.Close()
+					return err
+				}
+				n++
+				if err := fn(&s); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if n < exportFetch {
+				return nil
+			}
+		}
+	})
+}
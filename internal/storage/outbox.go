@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+// emit пишет событие в outbox; вызывать внутри транзакции изменения, чтобы событие не потерялось и не опередило данные
+func (r *Repository) emit(ctx context.Context, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, outboxInsert, event, b)
+	return err
+}
+
+const outboxInsert = `INSERT INTO outbox (event, payload) VALUES ($1, $2)`
+
+// DispatchOutbox раскладывает новые события outbox по активным вебхукам, подписанным на них.
+// Возвращает число обработанных событий; SKIP LOCKED позволяет запускать на нескольких инстансах.
+func (r *Repository) DispatchOutbox(ctx context.Context, limit int) (int64, error) {
+	ct, err := r.db.Exec(ctx, `
+		WITH ev AS (
+		 SELECT id, event FROM outbox
+		 WHERE dispatched_at IS NULL
+		 ORDER BY id
+		 LIMIT $1
+		 FOR UPDATE SKIP LOCKED
+		), ins AS (
+		 INSERT INTO webhook_deliveries (webhook_id, event_id)
+		 SELECT w.id, ev.id FROM ev
+		 JOIN webhooks w ON w.active AND (cardinality(w.events) = 0 OR ev.event = ANY(w.events))
+		 ON CONFLICT DO NOTHING
+		)
+		UPDATE outbox SET dispatched_at = now() WHERE id IN (SELECT id FROM ev)`, limit)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// EmitEndingSoon: событие subscription.ending_soon для подписок, заканчивающихся в ближайшие days дней.
+// Для одной подписки и end_date событие создаётся один раз.
+func (r *Repository) EmitEndingSoon(ctx context.Context, days int) (int64, error) {
+	ct, err := r.db.Exec(ctx, `
+		INSERT INTO outbox (event, payload, dedup_key)
+		SELECT $1, jsonb_build_object(
+		  'id', s.id, 'service_name', s.service_name, 'user_id', s.user_id,
+		  'end_date', s.end_date, 'days_left', s.end_date - CURRENT_DATE),
+		 'ending_soon:' || s.id || ':' || s.end_date
+		FROM subscriptions s
+		WHERE s.deleted_at IS NULL AND s.end_date BETWEEN CURRENT_DATE AND CURRENT_DATE + $2::int
+		ON CONFLICT (dedup_key) DO NOTHING`, model.EventSubscriptionEndingSoon, days)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// PendingDelivery: доставка, взятая диспетчером в работу
+type PendingDelivery struct {
+	ID       int64
+	Attempts int // уже сделанные попытки
+	URL      string
+	Secret   string
+	Event    model.WebhookEvent
+}
+
+// ClaimDeliveries берёт до limit доставок, которым пора отправляться, и откладывает их на lease,
+// чтобы другой инстанс не взял их повторно, пока идёт отправка. Доставки выключенных вебхуков ждут включения.
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]PendingDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + $2::interval, updated_at = now()
+		FROM webhooks w, outbox o
+		WHERE w.id = d.webhook_id AND o.id = d.event_id AND w.active
+		  AND d.id IN (
+		   SELECT pd.id FROM webhook_deliveries pd
+		   JOIN webhooks pw ON pw.id = pd.webhook_id
+		   WHERE pd.status = 'pending' AND pd.next_attempt_at <= now() AND pw.active
+		   ORDER BY pd.next_attempt_at
+		   LIMIT $1
+		   FOR UPDATE OF pd SKIP LOCKED)
+		RETURNING d.id, d.attempts, w.url, w.secret, o.id, o.event, o.created_at, o.payload`, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []PendingDelivery
+	for rows.Next() {
+		var d PendingDelivery
+		if err := rows.Scan(&d.ID, &d.Attempts, &d.URL, &d.Secret, &d.Event.ID, &d.Event.Type, &d.Event.CreatedAt, &d.Event.Data); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+// FinishDelivery записывает результат попытки: delivered при успехе,
+// иначе pending с новой попыткой в next или dead, если next == nil
+func (r *Repository) FinishDelivery(ctx context.Context, id int64, ok bool, code *int, errText *string, next *time.Time) error {
+	status := model.DeliveryDelivered
+	if !ok {
+		status = model.DeliveryPending
+		if next == nil {
+			status = model.DeliveryDead
+		}
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status=$2, attempts = attempts + 1, last_status_code=$3, last_error=$4,
+		    next_attempt_at = COALESCE($5, next_attempt_at), updated_at=now()
+		WHERE id=$1`, id, status, code, errText, next)
+	return err
+}
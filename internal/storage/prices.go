@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ListPrices: история цен подписки по возрастанию valid_from; nil — подписки нет
+func (r *Repository) ListPrices(ctx context.Context, id uuid.UUID) ([]model.PriceChange, error) {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id=$1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT price, valid_from, created_at FROM subscription_prices
+		WHERE subscription_id=$1 ORDER BY valid_from`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.PriceChange{}
+	for rows.Next() {
+		var p model.PriceChange
+		if err := rows.Scan(&p.Price, &p.ValidFrom, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// AddPrice: новая цена с даты validFrom (запись на ту же дату перезаписывается).
+// subscriptions.price синхронизируется с ценой, действующей сегодня. Любое изменение истории цен —
+// в том числе будущей или прошлой — меняет версию подписки; повтор той же цены ничего не меняет.
+// false — подписки нет.
+func (r *Repository) AddPrice(ctx context.Context, id uuid.UUID, price int, validFrom time.Time) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lock(ctx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		ok = true
+		ct, err := tx.db.Exec(ctx, `
+			INSERT INTO subscription_prices (subscription_id, price, valid_from)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (subscription_id, valid_from) DO UPDATE SET price = EXCLUDED.price, created_at = now()
+			WHERE subscription_prices.price <> EXCLUDED.price
+		`, id, price, validFrom)
+		if err != nil {
+			return err
+		}
+		if ct.RowsAffected() == 0 {
+			return nil
+		}
+		_, err = tx.db.Exec(ctx, `
+			UPDATE subscriptions SET price = price_at(id, CURRENT_DATE), updated_at = now(), version = version + 1
+			WHERE id=$1
+		`, id)
+		if err != nil {
+			return err
+		}
+		return tx.changed(ctx, model.EventSubscriptionUpdated, id, before)
+	})
+	return ok, err
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// QueuedJob: задача очереди, взятая воркером
+type QueuedJob struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int // с учётом текущей
+	MaxAttempts int
+}
+
+// JobSchedule: состояние расписания
+type JobSchedule struct {
+	Kind      string
+	Spec      string
+	NextRunAt time.Time
+}
+
+// EnqueueJob ставит задачу в очередь. С непустым dedupKey задача ставится один раз: false — такая уже есть.
+func (r *Repository) EnqueueJob(ctx context.Context, kind string, payload []byte, runAt time.Time, maxAttempts int, dedupKey string) (bool, error) {
+	ct, err := r.db.Exec(ctx, `
+		INSERT INTO jobs (kind, payload, run_at, max_attempts, dedup_key)
+		VALUES ($1, COALESCE($2::jsonb, '{}'), $3, $4, NULLIF($5, ''))
+		ON CONFLICT (dedup_key) DO NOTHING`, kind, payload, runAt, maxAttempts, dedupKey)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// ClaimQueueJobs берёт до limit задач из kinds, которым пора выполняться (и running с истёкшей арендой —
+// их инстанс упал), и продлевает аренду на lease. Задачи, потерявшие исполнителя на последней попытке, — dead.
+func (r *Repository) ClaimQueueJobs(ctx context.Context, kinds []string, limit int, lease time.Duration) ([]QueuedJob, error) {
+	if _, err := r.db.Exec(ctx, `
+		UPDATE jobs SET status='dead', last_error='worker lost', locked_until=NULL, finished_at=now(), updated_at=now()
+		WHERE status='running' AND locked_until < now() AND attempts >= max_attempts`); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		UPDATE jobs
+		SET status='running', attempts = attempts + 1, locked_until = now() + $3::interval, updated_at=now()
+		WHERE id IN (
+		   SELECT id FROM jobs
+		   WHERE kind = ANY($1)
+		     AND ((status='pending' AND run_at <= now()) OR (status='running' AND locked_until < now()))
+		   ORDER BY run_at
+		   LIMIT $2
+		   FOR UPDATE SKIP LOCKED)
+		RETURNING id, kind, payload, attempts, max_attempts`, kinds, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []QueuedJob
+	for rows.Next() {
+		var j QueuedJob
+		if err := rows.Scan(&j.ID, &j.Kind, &j.Payload, &j.Attempts, &j.MaxAttempts); err != nil {
+			return nil, err
+		}
+		res = append(res, j)
+	}
+	return res, rows.Err()
+}
+
+// FinishQueueJob записывает результат: done при успехе,
+// иначе pending с новой попыткой в next или dead, если next == nil.
+// attempt — номер попытки из ClaimQueueJobs; задачу, которую уже забрал другой воркер, не трогает (ErrLeaseLost).
+func (r *Repository) FinishQueueJob(ctx context.Context, id int64, attempt int, errText *string, next *time.Time) error {
+	status := "done"
+	if errText != nil {
+		status = "pending"
+		if next == nil {
+			status = "dead"
+		}
+	}
+	ct, err := r.db.Exec(ctx, `
+		UPDATE jobs
+		SET status=$3, last_error=$4, run_at = COALESCE($5, run_at), locked_until=NULL, updated_at=now(),
+		    finished_at = CASE WHEN $3 = 'pending' THEN NULL ELSE now() END
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt, status, errText, next)
+	return leaseResult(ct, err)
+}
+
+// ReleaseQueueJob возвращает прерванную остановкой сервера задачу в очередь; попытка не засчитывается
+func (r *Repository) ReleaseQueueJob(ctx context.Context, id int64, attempt int) error {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE jobs SET status='pending', attempts = GREATEST(attempts - 1, 0), locked_until=NULL, updated_at=now()
+		WHERE id=$1 AND status='running' AND attempts=$2`, id, attempt)
+	return leaseResult(ct, err)
+}
+
+// PurgeQueueJobs удаляет завершённые (done и dead) задачи старше before
+func (r *Repository) PurgeQueueJobs(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM jobs WHERE status IN ('done', 'dead') AND finished_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+func (r *Repository) ListJobSchedules(ctx context.Context) (map[string]JobSchedule, error) {
+	rows, err := r.db.Query(ctx, `SELECT kind, spec, next_run_at FROM job_schedules`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[string]JobSchedule{}
+	for rows.Next() {
+		var s JobSchedule
+		if err := rows.Scan(&s.Kind, &s.Spec, &s.NextRunAt); err != nil {
+			return nil, err
+		}
+		res[s.Kind] = s
+	}
+	return res, rows.Err()
+}
+
+// SetJobSchedule: новое расписание или смена spec — следующий запуск считается заново
+func (r *Repository) SetJobSchedule(ctx context.Context, kind, spec string, next time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO job_schedules (kind, spec, next_run_at) VALUES ($1, $2, $3)
+		ON CONFLICT (kind) DO UPDATE SET spec=EXCLUDED.spec, next_run_at=EXCLUDED.next_run_at, updated_at=now()`,
+		kind, spec, next)
+	return err
+}
+
+// FireJobSchedule ставит в очередь запуск, назначенный на due, и переносит расписание на next.
+// Запуск ставится один раз, даже если лидер сменился посреди тика: false — его уже поставили.
+func (r *Repository) FireJobSchedule(ctx context.Context, kind string, due, next time.Time, maxAttempts int) (bool, error) {
+	fired := false
+	err := r.inTx(ctx, func(tx *Repository) error {
+		ct, err := tx.db.Exec(ctx, `
+			UPDATE job_schedules SET next_run_at=$3, last_run_at=now(), updated_at=now()
+			WHERE kind=$1 AND next_run_at=$2`, kind, due, next)
+		if err != nil || ct.RowsAffected() == 0 {
+			return err
+		}
+		fired, err = tx.EnqueueJob(ctx, kind, nil, time.Now(), maxAttempts, "schedule:"+kind+":"+due.UTC().Format(time.RFC3339))
+		return err
+	})
+	return fired, err
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpsertRates: вставляет курсы, существующие (currency, valid_from) перезаписываются
+func (r *Repository) UpsertRates(ctx context.Context, rates []model.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (currency, rate, valid_from)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (currency, valid_from) DO UPDATE SET rate = EXCLUDED.rate
+	`
+	batch := &pgx.Batch{}
+	for _, rt := range rates {
+		batch.Queue(query, rt.Currency, rt.Rate, rt.ValidFrom)
+	}
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+func (r *Repository) ListRates(ctx context.Context, currency *string) ([]model.ExchangeRate, error) {
+	q := `SELECT currency, rate::float8, valid_from FROM exchange_rates`
+	args := []any{}
+	if currency != nil {
+		q += " WHERE currency=$1"
+		args = append(args, *currency)
+	}
+	q += " ORDER BY currency, valid_from"
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.ExchangeRate{}
+	for rows.Next() {
+		var rt model.ExchangeRate
+		if err := rows.Scan(&rt.Currency, &rt.Rate, &rt.ValidFrom); err != nil {
+			return nil, err
+		}
+		res = append(res, rt)
+	}
+	return res, rows.Err()
+}
@@ -4,41 +4,95 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dbtx: общее у *pgxpool.Pool и pgx.Tx, чтобы методы репозитория работали и внутри транзакции
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type Repository struct {
-	pool *pgxpool.Pool
+	db dbtx
 }
 
+// ErrPrecondition: версия подписки не совпала с ожидаемой (If-Match)
+var ErrPrecondition = errors.New("version mismatch")
+
 func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{db: pool}
+}
+
+// inTx выполняет fn в транзакции; если репозиторий уже в транзакции — во вложенной (savepoint)
+func (r *Repository) inTx(ctx context.Context, fn func(tx *Repository) error) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return fn(&Repository{db: tx})
+	})
+}
+
+// InTx: несколько операций репозитория в одной транзакции (пакетные запросы).
+// Методы tx, которые сами открывают транзакцию, работают в savepoint: их ошибка откатывает только их изменения.
+func (r *Repository) InTx(ctx context.Context, fn func(tx *Repository) error) error {
+	return r.inTx(ctx, fn)
+}
+
+// subscriptionColumns: порядок колонок должен совпадать со scanSubscription.
+// Теги — подзапросом по subscriptions.id, поэтому таблицу в FROM не алиасим.
+const subscriptionColumns = `id, service_name, service_id, price, currency, billing_period, billing_interval, user_id, start_date, end_date, created_at, updated_at, deleted_at, version,
+	ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+		WHERE st.subscription_id = subscriptions.id ORDER BY t.name) AS tags`
+
+func scanSubscription(row pgx.Row, s *model.Subscription) error {
+	return row.Scan(&s.ID, &s.ServiceName, &s.ServiceID, &s.Price, &s.Currency, &s.BillingPeriod, &s.BillingInterval,
+		&s.UserID, &s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt, &s.DeletedAt, &s.Version, &s.Tags)
 }
 
 func (r *Repository) Create(ctx context.Context, s *model.Subscription) (uuid.UUID, error) {
 	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at
+		INSERT INTO subscriptions (service_name, service_id, price, currency, billing_period, billing_interval, user_id, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at, version
 	`
-	row := r.pool.QueryRow(ctx, query, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate)
-	if err := row.Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+	err := r.inTx(ctx, func(tx *Repository) error {
+		row := tx.db.QueryRow(ctx, query, s.ServiceName, s.ServiceID, s.Price, s.Currency, s.BillingPeriod, s.BillingInterval, s.UserID, s.StartDate, s.EndDate)
+		if err := row.Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt, &s.Version); err != nil {
+			return err
+		}
+		// первая запись истории цен — с даты начала подписки
+		if _, err := tx.db.Exec(ctx, `INSERT INTO subscription_prices (subscription_id, price, valid_from) VALUES ($1, $2, $3)`,
+			s.ID, s.Price, s.StartDate); err != nil {
+			return err
+		}
+		if _, err := tx.addTags(ctx, s.ID, s.Tags); err != nil {
+			return err
+		}
+		return tx.changed(ctx, model.EventSubscriptionCreated, s.ID, nil)
+	})
+	if err != nil {
 		return uuid.Nil, err
 	}
 	return s.ID, nil
 }
 
+// Get: подписки в корзине не возвращаются
 func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
 	var s model.Subscription
-	query := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at FROM subscriptions WHERE id=$1`
-	row := r.pool.QueryRow(ctx, query, id)
-	if err := row.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id=$1 AND deleted_at IS NULL`
+	row := r.db.QueryRow(ctx, query, id)
+	if err := scanSubscription(row, &s); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
@@ -47,122 +101,143 @@ func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*model.Subscription
 	return &s, nil
 }
 
-func (r *Repository) Update(ctx context.Context, id uuid.UUID, s *model.Subscription) (bool, error) {
+// Update перезаписывает поля подписки. Если цена изменилась, она записывается в историю
+// с начала текущего месяца (или с start_date, если подписка ещё не началась), прошлые месяцы не меняются.
+// Теги заменяются, только если s.Tags != nil. ifMatch — допустимые текущие версии (nil — любая),
+// при несовпадении ErrPrecondition; новая версия записывается в s.Version.
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, s *model.Subscription, ifMatch []int) (bool, error) {
 	query := `
 		UPDATE subscriptions
-		SET service_name=$1, price=$2, user_id=$3, start_date=$4, end_date=$5, updated_at=now()
-		WHERE id=$6
+		SET service_name=$1, service_id=$2, price=$3, currency=$4, billing_period=$5, billing_interval=$6,
+			user_id=$7, start_date=$8, end_date=$9, updated_at=now(), version=version+1
+		WHERE id=$10
+		RETURNING version
 	`
-	ct, err := r.pool.Exec(ctx, query, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, id)
-	if err != nil {
-		return false, err
+	effective := monthStart(time.Now().UTC())
+	if s.StartDate.After(effective) {
+		effective = s.StartDate
 	}
-	return ct.RowsAffected() == 1, nil
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lock(ctx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		ok = true
+		if err := checkVersion(before, ifMatch); err != nil {
+			return err
+		}
+		if err := tx.db.QueryRow(ctx, query, s.ServiceName, s.ServiceID, s.Price, s.Currency, s.BillingPeriod, s.BillingInterval, s.UserID, s.StartDate, s.EndDate, id).Scan(&s.Version); err != nil {
+			return err
+		}
+		// историю трогаем, только если цену действительно меняют: иначе после AddPrice с середины месяца
+		// та же цена переписала бы начало месяца задним числом
+		if s.Price != before.Price {
+			if _, err = tx.db.Exec(ctx, `
+				INSERT INTO subscription_prices (subscription_id, price, valid_from)
+				SELECT $1, $2, $3 WHERE price_at($1, $3) IS DISTINCT FROM $2
+				ON CONFLICT (subscription_id, valid_from) DO UPDATE SET price = EXCLUDED.price
+			`, id, s.Price, effective); err != nil {
+				return err
+			}
+		}
+		if s.Tags != nil {
+			if _, err := tx.db.Exec(ctx, `DELETE FROM subscription_tags WHERE subscription_id=$1`, id); err != nil {
+				return err
+			}
+			if _, err := tx.addTags(ctx, id, s.Tags); err != nil {
+				return err
+			}
+		}
+		return tx.changed(ctx, model.EventSubscriptionUpdated, id, before)
+	})
+	return ok, err
 }
 
-func (r *Repository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
-	ct, err := r.pool.Exec(ctx, `DELETE FROM subscriptions WHERE id=$1`, id)
+// Delete переносит подписку в корзину: из списков и сводок она пропадает, но её можно восстановить,
+// пока не истёк срок хранения (PurgeTrash). В аудит и событие subscription.deleted попадает подписка до удаления.
+// ifMatch — как в Update.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID, ifMatch []int) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lock(ctx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		ok = true
+		if err := checkVersion(before, ifMatch); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `UPDATE subscriptions SET deleted_at=now(), updated_at=now(), version=version+1 WHERE id=$1`, id); err != nil {
+			return err
+		}
+		return tx.changed(ctx, model.EventSubscriptionDeleted, id, before)
+	})
+	return ok, err
+}
+
+// Restore возвращает подписку из корзины; nil — в корзине её нет
+func (r *Repository) Restore(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	var s *model.Subscription
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lockRow(ctx, id, true)
+		if err != nil || before == nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `UPDATE subscriptions SET deleted_at=NULL, updated_at=now(), version=version+1 WHERE id=$1`, id); err != nil {
+			return err
+		}
+		if err := tx.changed(ctx, model.EventSubscriptionRestored, id, before); err != nil {
+			return err
+		}
+		s, err = tx.Get(ctx, id)
+		return err
+	})
+	return s, err
+}
+
+// PurgeTrash окончательно удаляет подписки, попавшие в корзину раньше before; возвращает их число
+func (r *Repository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM subscriptions WHERE deleted_at < $1`, before)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	return ct.RowsAffected() == 1, nil
+	return ct.RowsAffected(), nil
 }
 
-type ListFilter struct {
-	UserID      *uuid.UUID
-	ServiceName *string
-	Limit       int
-	Offset      int
+// lock: подписка с блокировкой строки до конца транзакции (состояние «до» для аудита); nil — нет или она в корзине
+func (r *Repository) lock(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	return r.lockRow(ctx, id, false)
 }
 
-func (r *Repository) List(ctx context.Context, f ListFilter) ([]model.Subscription, error) {
-	q := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-		FROM subscriptions WHERE 1=1`
-	args := []any{}
-	idx := 1
-
-	if f.UserID != nil {
-		q += " AND user_id=$" + itoa(idx)
-		args = append(args, *f.UserID)
-		idx++
-	}
-	if f.ServiceName != nil {
-		q += " AND service_name=$" + itoa(idx)
-		args = append(args, *f.ServiceName)
-		idx++
-	}
-	q += " ORDER BY created_at DESC"
-	if f.Limit > 0 {
-		q += " LIMIT $" + itoa(idx)
-		args = append(args, f.Limit)
-		idx++
+// lockRow: как lock, trashed выбирает подписки в корзине вместо обычных
+func (r *Repository) lockRow(ctx context.Context, id uuid.UUID, trashed bool) (*model.Subscription, error) {
+	cond := "deleted_at IS NULL"
+	if trashed {
+		cond = "deleted_at IS NOT NULL"
 	}
-	if f.Offset > 0 {
-		q += " OFFSET $" + itoa(idx)
-		args = append(args, f.Offset)
-		idx++
+	var s model.Subscription
+	err := scanSubscription(r.db.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id=$1 AND `+cond+` FOR UPDATE`, id), &s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
 	}
-
-	rows, err := r.pool.Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return &s, nil
+}
 
-	var res []model.Subscription
-	for rows.Next() {
-		var s model.Subscription
-		if err := rows.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
-			return nil, err
-		}
-		res = append(res, s)
+func checkVersion(s *model.Subscription, ifMatch []int) error {
+	if ifMatch != nil && !slices.Contains(ifMatch, s.Version) {
+		return ErrPrecondition
 	}
-	return res, rows.Err()
-}
-
-// Summary: сумма price * кол-во активных месяцев в интервале [from,to]
-func (r *Repository) Summary(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int64, error) {
-	q := `
-SELECT
- COALESCE(SUM(
-  price * (
-   (DATE_PART('year', ato) - DATE_PART('year', afrom)) * 12
-   + (DATE_PART('month', ato) - DATE_PART('month', afrom)) + 1
-  )
- ), 0) AS total
-FROM (
- SELECT
-  s.price,
-  GREATEST(date_trunc('month', $1::date), date_trunc('month', s.start_date)) AS afrom,
-  LEAST(date_trunc('month', COALESCE(s.end_date, $2::date)), date_trunc('month', $2::date)) AS ato
- FROM subscriptions s
- WHERE s.start_date <= $2::date
-   AND COALESCE(s.end_date, '9999-12-31') >= $1::date
-   %s
-) t;
-`
-	// filters
-	filter := ""
-	args := []any{from, to}
-	idx := 3
-
-	if userID != nil {
-		filter += " AND s.user_id=$" + itoa(idx)
-		args = append(args, *userID)
-		idx++
-	}
-	if serviceName != nil {
-		filter += " AND s.service_name=$" + itoa(idx)
-		args = append(args, *serviceName)
-		idx++
-	}
-
-	query := sprintf(q, filter)
-	var total int64
-	err := r.pool.QueryRow(ctx, query, args...).Scan(&total)
-	return total, err
+	return nil
 }
 
 // helpers
 func itoa(i int) string                 { return fmt.Sprintf("%d", i) }
 func sprintf(f string, a ...any) string { return fmt.Sprintf(f, a...) }
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// Цена сменилась с середины месяца через AddPrice; PUT с той же ценой не должен переписывать начало месяца.
+func TestUpdateKeepsMidMonthPrice(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewRepository(pool)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	month := monthStart(today)
+	if today.Equal(month) {
+		t.Skip("нужен день не в начале месяца")
+	}
+	s := &model.Subscription{
+		ServiceName:     "Test",
+		Price:           100,
+		Currency:        "RUB",
+		BillingPeriod:   model.BillingMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       month.AddDate(0, -2, 0),
+	}
+	id, err := repo.Create(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AddPrice(ctx, id, 200, today); err != nil {
+		t.Fatal(err)
+	}
+	cur, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur.Price != 200 {
+		t.Fatalf("price = %d after AddPrice, want 200", cur.Price)
+	}
+	cur.Tags = nil
+	if _, err := repo.Update(ctx, id, cur, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var atMonth int
+	if err := pool.QueryRow(ctx, `SELECT price_at($1, $2)`, id, month).Scan(&atMonth); err != nil {
+		t.Fatal(err)
+	}
+	if atMonth != 100 {
+		t.Fatalf("price at %s = %d, want 100", month.Format(time.DateOnly), atMonth)
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+)
+
+// ServiceMatch: как сравнивать service_name с фильтром
+type ServiceMatch string
+
+const (
+	MatchExact  ServiceMatch = "exact"  // точное совпадение (по умолчанию)
+	MatchCI     ServiceMatch = "ci"     // без учёта регистра
+	MatchPrefix ServiceMatch = "prefix" // начинается с, без учёта регистра
+	MatchFuzzy  ServiceMatch = "fuzzy"  // триграммное сходство pg_trgm (similarity_threshold)
+)
+
+func (m ServiceMatch) Valid() bool {
+	switch m {
+	case MatchExact, MatchCI, MatchPrefix, MatchFuzzy:
+		return true
+	}
+	return false
+}
+
+// serviceNameCond: условие «col совпадает с любым из names» в режиме m.
+// "?" в условии — место единственного параметра, второе значение — его аргумент.
+func serviceNameCond(col string, m ServiceMatch, names []string) (string, any) {
+	switch m {
+	case MatchCI:
+		return "lower(" + col + ") = ANY(?::text[])", lowerAll(names, "")
+	case MatchPrefix:
+		return "lower(" + col + ") LIKE ANY(?::text[])", lowerAll(names, "%")
+	case MatchFuzzy:
+		return "EXISTS (SELECT 1 FROM unnest(?::text[]) n WHERE " + col + " % n)", names
+	default:
+		return col + " = ANY(?::text[])", names
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// lowerAll: lower-case значений; с suffix — ещё и экранирование для LIKE
+func lowerAll(names []string, suffix string) []string {
+	res := make([]string, len(names))
+	for i, n := range names {
+		n = strings.ToLower(n)
+		if suffix != "" {
+			n = likeEscaper.Replace(n) + suffix
+		}
+		res[i] = n
+	}
+	return res
+}
+
+// ServiceNames: известные названия сервисов для автодополнения. Написания, отличающиеся регистром,
+// склеиваются (показывается самое частое). Сначала совпадения по префиксу, затем по популярности
+// (число пользователей) и сходству с q. Пустой q — просто самые популярные.
+func (r *Repository) ServiceNames(ctx context.Context, q string, limit int) ([]model.ServiceNameSuggestion, error) {
+	query := `
+SELECT
+ mode() WITHIN GROUP (ORDER BY service_name) AS name,
+ COUNT(*) AS subscriptions,
+ COUNT(DISTINCT user_id) AS users
+FROM subscriptions
+WHERE deleted_at IS NULL
+ AND ($1::text = '' OR lower(service_name) LIKE $2::text OR service_name % $1::text)
+GROUP BY lower(service_name)
+ORDER BY bool_or(lower(service_name) LIKE $2::text) DESC,
+ COUNT(DISTINCT user_id) DESC,
+ MAX(similarity(service_name, $1::text)) DESC,
+ name
+LIMIT $3
+`
+	q = strings.TrimSpace(q)
+	rows, err := r.db.Query(ctx, query, q, lowerAll([]string{q}, "%")[0], limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.ServiceNameSuggestion{}
+	for rows.Next() {
+		var s model.ServiceNameSuggestion
+		if err := rows.Scan(&s.Name, &s.Subscriptions, &s.Users); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
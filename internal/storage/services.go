@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrConflict: нарушение уникальности (например, название или алиас уже заняты другой записью)
+var ErrConflict = errors.New("conflict")
+
+const serviceColumns = `id, name, aliases, category, default_price, currency, homepage, created_at, updated_at`
+
+func scanService(row pgx.Row, s *model.Service) error {
+	return row.Scan(&s.ID, &s.Name, &s.Aliases, &s.Category, &s.DefaultPrice, &s.Currency, &s.Homepage, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// checkServiceNames: ни название, ни алиасы не должны совпадать с названием или алиасом другой записи.
+// Проверка даёт понятное сообщение; параллельные запросы разводит ограничение service_names (serviceConflict).
+func (r *Repository) checkServiceNames(ctx context.Context, id uuid.UUID, s *model.Service) error {
+	names := lowerAll(append([]string{s.Name}, s.Aliases...), "")
+	var taken string
+	err := r.db.QueryRow(ctx, `
+		SELECT name FROM services
+		WHERE id <> $1
+		  AND (lower(name) = ANY($2::text[]) OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = ANY($2::text[])))
+		LIMIT 1`, id, names).Scan(&taken)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: name or alias already used by %q", ErrConflict, taken)
+}
+
+// linkSubscriptions: привязывает к записи каталога ещё не привязанные подписки с совпадающим названием
+// и приводит service_name всех привязанных подписок к названию записи (после переименования в том числе).
+// Каждая изменённая подписка попадает в аудит и события, как при обычном изменении; подписки в корзине
+// обновляются молча — для клиентов они не меняются, а при восстановлении будет своё событие.
+func (r *Repository) linkSubscriptions(ctx context.Context, s *model.Service) error {
+	names := lowerAll(append([]string{s.Name}, s.Aliases...), "")
+	const match = `((service_id IS NULL AND lower(service_name) = ANY($1::text[])) OR (service_id = $2 AND service_name <> $3))`
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE `+match+` AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE`, names, s.ID, s.Name)
+	if err != nil {
+		return err
+	}
+	before, err := collectSubscriptions(rows)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, `
+		UPDATE subscriptions SET service_id = $2, service_name = $3
+		WHERE `+match+` AND deleted_at IS NOT NULL`,
+		names, s.ID, s.Name); err != nil {
+		return err
+	}
+	if len(before) == 0 {
+		return nil
+	}
+	if _, err := r.db.Exec(ctx, `
+		UPDATE subscriptions SET service_id = $1, service_name = $2, updated_at = now(), version = version + 1
+		WHERE id = ANY($3)`, s.ID, s.Name, subscriptionIDs(before)); err != nil {
+		return err
+	}
+	return r.changedAll(ctx, before)
+}
+
+// collectSubscriptions: читает все строки выборки subscriptionColumns и закрывает rows
+func collectSubscriptions(rows pgx.Rows) ([]model.Subscription, error) {
+	defer rows.Close()
+	var res []model.Subscription
+	for rows.Next() {
+		var sub model.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, err
+		}
+		res = append(res, sub)
+	}
+	return res, rows.Err()
+}
+
+func subscriptionIDs(subs []model.Subscription) []uuid.UUID {
+	ids := make([]uuid.UUID, len(subs))
+	for i := range subs {
+		ids[i] = subs[i].ID
+	}
+	return ids
+}
+
+// changedAll: аудит и событие обновления для каждой подписки из before
+func (r *Repository) changedAll(ctx context.Context, before []model.Subscription) error {
+	for i := range before {
+		if err := r.changed(ctx, model.EventSubscriptionUpdated, before[i].ID, &before[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) CreateService(ctx context.Context, s *model.Service) error {
+	return r.inTx(ctx, func(tx *Repository) error {
+		if err := tx.checkServiceNames(ctx, uuid.Nil, s); err != nil {
+			return err
+		}
+		row := tx.db.QueryRow(ctx, `
+			INSERT INTO services (name, aliases, category, default_price, currency, homepage)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at, updated_at`,
+			s.Name, s.Aliases, s.Category, s.DefaultPrice, s.Currency, s.Homepage)
+		if err := row.Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return serviceConflict(err)
+		}
+		return tx.linkSubscriptions(ctx, s)
+	})
+}
+
+func (r *Repository) GetService(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	var s model.Service
+	err := scanService(r.db.QueryRow(ctx, `SELECT `+serviceColumns+` FROM services WHERE id=$1`, id), &s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListServices: каталог по названию, опционально только одна категория (без учёта регистра)
+func (r *Repository) ListServices(ctx context.Context, category *string) ([]model.Service, error) {
+	q := `SELECT ` + serviceColumns + ` FROM services`
+	args := []any{}
+	if category != nil {
+		q += " WHERE lower(category) = lower($1)"
+		args = append(args, *category)
+	}
+	q += " ORDER BY lower(name)"
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Service{}
+	for rows.Next() {
+		var s model.Service
+		if err := scanService(rows, &s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repository) UpdateService(ctx context.Context, id uuid.UUID, s *model.Service) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		if err := tx.checkServiceNames(ctx, id, s); err != nil {
+			return err
+		}
+		row := tx.db.QueryRow(ctx, `
+			UPDATE services
+			SET name=$1, aliases=$2, category=$3, default_price=$4, currency=$5, homepage=$6, updated_at=now()
+			WHERE id=$7
+			RETURNING id, created_at, updated_at`,
+			s.Name, s.Aliases, s.Category, s.DefaultPrice, s.Currency, s.Homepage, id)
+		err := row.Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return serviceConflict(err)
+		}
+		ok = true
+		return tx.linkSubscriptions(ctx, s)
+	})
+	return ok, err
+}
+
+// DeleteService: подписки остаются, связь с каталогом обнуляется. Активные подписки отвязываются явно —
+// с новой версией, аудитом и событием; подписки в корзине отвязывает ON DELETE SET NULL.
+func (r *Repository) DeleteService(ctx context.Context, id uuid.UUID) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		rows, err := tx.db.Query(ctx, `
+			SELECT `+subscriptionColumns+` FROM subscriptions
+			WHERE service_id = $1 AND deleted_at IS NULL
+			ORDER BY id
+			FOR UPDATE`, id)
+		if err != nil {
+			return err
+		}
+		before, err := collectSubscriptions(rows)
+		if err != nil {
+			return err
+		}
+		if len(before) > 0 {
+			if _, err := tx.db.Exec(ctx, `
+				UPDATE subscriptions SET service_id = NULL, updated_at = now(), version = version + 1
+				WHERE id = ANY($1)`, subscriptionIDs(before)); err != nil {
+				return err
+			}
+		}
+		ct, err := tx.db.Exec(ctx, `DELETE FROM services WHERE id=$1`, id)
+		if err != nil {
+			return err
+		}
+		ok = ct.RowsAffected() == 1
+		return tx.changedAll(ctx, before)
+	})
+	return ok, err
+}
+
+// ResolveService: запись каталога, чьё название или алиас совпадает с name без учёта регистра; nil — не найдена
+func (r *Repository) ResolveService(ctx context.Context, name string) (*model.Service, error) {
+	var s model.Service
+	err := scanService(r.db.QueryRow(ctx, `
+		SELECT `+serviceColumns+` FROM services
+		WHERE lower(name) = $1 OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = $1)
+		ORDER BY lower(name) = $1 DESC
+		LIMIT 1`, strings.ToLower(strings.TrimSpace(name))), &s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// serviceConflict: название или алиас успел занять параллельный запрос
+func serviceConflict(err error) error {
+	if err = mapConflict(err); errors.Is(err, ErrConflict) {
+		return fmt.Errorf("%w: name or alias already used", ErrConflict)
+	}
+	return err
+}
+
+func mapConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConflict
+	}
+	return err
+}
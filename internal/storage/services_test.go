@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// Два запроса одновременно заводят записи с одним алиасом: проверка SELECT-ом не видит незафиксированную
+// запись соседа, поэтому второй должен упереться в ограничение базы.
+func TestCreateServiceConcurrentAlias(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewRepository(pool)
+	alias := "alias-" + uuid.NewString()
+	a := &model.Service{Name: "A " + alias, Aliases: []string{alias}, Currency: "RUB"}
+	b := &model.Service{Name: "B " + alias, Aliases: []string{alias}, Currency: "RUB"}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM services WHERE id = ANY($1)`, []uuid.UUID{a.ID, b.ID})
+	})
+
+	created, release := make(chan struct{}), make(chan struct{})
+	errA, errB := make(chan error, 1), make(chan error, 1)
+	go func() {
+		errA <- repo.InTx(ctx, func(tx *Repository) error {
+			err := tx.CreateService(ctx, a)
+			close(created)
+			<-release
+			return err
+		})
+	}()
+	<-created
+	go func() { errB <- repo.CreateService(ctx, b) }()
+	// B ждёт на уникальном индексе, пока A не зафиксируется
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+
+	if err := <-errA; err != nil {
+		t.Fatalf("A: %v", err)
+	}
+	if err := <-errB; !errors.Is(err, ErrConflict) {
+		t.Fatalf("B: err = %v, want ErrConflict", err)
+	}
+}
+
+// Подписка, привязанная к новой записи каталога, меняет версию — значит, должны быть и аудит, и событие.
+func TestCreateServiceLinksWithAudit(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewRepository(pool)
+	name := "svc-" + uuid.NewString()
+
+	sub := &model.Subscription{
+		ServiceName: name, Price: 100, Currency: "RUB", BillingPeriod: model.BillingMonthly, BillingInterval: 1,
+		UserID: uuid.New(), StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	id, err := repo.Create(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &model.Service{Name: name, Currency: "RUB"}
+	if err := repo.CreateService(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM services WHERE id=$1`, svc.ID) })
+
+	got, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceID == nil || *got.ServiceID != svc.ID || got.Version != sub.Version+1 {
+		t.Fatalf("service_id = %v, version = %d; want %s, %d", got.ServiceID, got.Version, svc.ID, sub.Version+1)
+	}
+	items, _, err := repo.ListAudit(ctx, AuditFilter{SubscriptionID: &id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Action != "updated" || items[0].After == nil || items[0].After.Version != got.Version {
+		t.Fatalf("audit = %+v, want updated entry on top of created", items)
+	}
+	var events int
+	if err := pool.QueryRow(ctx, `
+		SELECT count(*) FROM outbox WHERE event=$1 AND payload->>'id' = $2`,
+		model.EventSubscriptionUpdated, id.String()).Scan(&events); err != nil {
+		t.Fatal(err)
+	}
+	if events != 1 {
+		t.Fatalf("%d subscription.updated events, want 1", events)
+	}
+}
+
+// Переименование записи каталога меняет service_name привязанных подписок, удаление — отвязывает их;
+// оба изменения видны в версии и аудите.
+func TestRenameAndDeleteServiceSyncsSubscriptions(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewRepository(pool)
+	name := "svc-" + uuid.NewString()
+
+	svc := &model.Service{Name: name, Currency: "RUB"}
+	if err := repo.CreateService(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM services WHERE id=$1`, svc.ID) })
+	sub := &model.Subscription{
+		ServiceName: name, ServiceID: &svc.ID, Price: 100, Currency: "RUB", BillingPeriod: model.BillingMonthly,
+		BillingInterval: 1, UserID: uuid.New(), StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	id, err := repo.Create(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := &model.Service{Name: name + " renamed", Currency: "RUB"}
+	if ok, err := repo.UpdateService(ctx, svc.ID, renamed); err != nil || !ok {
+		t.Fatalf("UpdateService = %v, %v", ok, err)
+	}
+	got, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceName != renamed.Name || got.Version != sub.Version+1 {
+		t.Fatalf("after rename: service_name = %q, version = %d; want %q, %d", got.ServiceName, got.Version, renamed.Name, sub.Version+1)
+	}
+
+	if ok, err := repo.DeleteService(ctx, svc.ID); err != nil || !ok {
+		t.Fatalf("DeleteService = %v, %v", ok, err)
+	}
+	got, err = repo.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceID != nil || got.Version != sub.Version+2 {
+		t.Fatalf("after delete: service_id = %v, version = %d; want nil, %d", got.ServiceID, got.Version, sub.Version+2)
+	}
+	items, _, err := repo.ListAudit(ctx, AuditFilter{SubscriptionID: &id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("%d audit entries, want created + 2 updated", len(items))
+	}
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SummaryMode: как считать стоимость подписок за период
+type SummaryMode string
+
+const (
+	// SummaryCharges: сумма фактических списаний, дата которых попала в период
+	SummaryCharges SummaryMode = "charges"
+	// SummaryAmortized: цена списания равномерно размазана по месяцам периода оплаты
+	SummaryAmortized SummaryMode = "amortized"
+	// SummaryProrated: как SummaryAmortized, но неполные месяцы считаются пропорционально дням
+	SummaryProrated SummaryMode = "prorated"
+)
+
+func (m SummaryMode) Valid() bool {
+	return m == SummaryCharges || m == SummaryAmortized || m == SummaryProrated
+}
+
+// Измерения разбивки Summary (group_by)
+const (
+	GroupMonth       = "month"
+	GroupServiceName = "service_name"
+	GroupUserID      = "user_id"
+	GroupCategory    = "category"
+	GroupTag         = "tag"
+)
+
+// summaryGroups: измерение -> выражение над строками conv. Только эти значения попадают в SQL.
+var summaryGroups = map[string]string{
+	GroupMonth:       `to_char(d, 'YYYY-MM')`,
+	GroupServiceName: `service_name`,
+	GroupUserID:      `user_id`,
+	GroupCategory:    `COALESCE(category, 'uncategorized')`,
+	GroupTag:         `tg.tag`,
+}
+
+func ValidGroup(g string) bool { _, ok := summaryGroups[g]; return ok }
+
+type SummaryFilter struct {
+	From         time.Time // первый день периода
+	To           time.Time // последний день периода (включительно)
+	UserID       *uuid.UUID
+	ServiceName  *string
+	ServiceMatch ServiceMatch // как сравнивать ServiceName, по умолчанию MatchExact
+	Category     *string      // категория каталога, без учёта регистра
+	Tags         []string     // есть любой из тегов
+	Currency     string       // валюта результата, по умолчанию RUB
+	Mode         SummaryMode  // по умолчанию SummaryCharges
+	GroupBy      []string     // измерения разбивки (Group*), пусто — только общий итог
+}
+
+// ErrNoRate: для какого-то месяца периода нет курса исходной или целевой валюты
+var ErrNoRate = errors.New("no exchange rate")
+
+// Источники начислений для Summary: строки (subscription_id, user_id, service_name, category, tags, price, currency, d),
+// d — дата, по которой берутся цена из истории и курс.
+// В CTE subs уже отфильтрованные подписки и hi — последний день, когда подписка активна в периоде.
+var summaryEvents = map[SummaryMode]string{
+	SummaryCharges: `
+ SELECT s.id AS subscription_id, s.user_id, s.service_name, s.category, s.tags,
+  COALESCE(price_at(s.id, d), s.price)::numeric AS price, s.currency, d
+ FROM subs s
+ CROSS JOIN LATERAL charge_dates(s.start_date, s.billing_period, s.billing_interval, $1::date, s.hi) d`,
+	SummaryAmortized: `
+ SELECT s.id AS subscription_id, s.user_id, s.service_name, s.category, s.tags,
+  COALESCE(price_at(s.id, gs::date), s.price) / billing_months(s.billing_period, s.billing_interval) AS price,
+  s.currency, gs::date AS d
+ FROM subs s
+ CROSS JOIN LATERAL generate_series(
+  date_trunc('month', GREATEST($1::date, s.start_date)),
+  date_trunc('month', s.hi),
+  interval '1 month'
+ ) gs`,
+	SummaryProrated: `
+ SELECT s.id AS subscription_id, s.user_id, s.service_name, s.category, s.tags,
+  COALESCE(price_at(s.id, gs::date), s.price) / billing_months(s.billing_period, s.billing_interval)
+  * (LEAST(s.hi, (gs + interval '1 month - 1 day')::date) - GREATEST(s.start_date, $1::date, gs::date) + 1)
+  / ((gs + interval '1 month')::date - gs::date)::numeric AS price,
+  s.currency, gs::date AS d
+ FROM subs s
+ CROSS JOIN LATERAL generate_series(
+  date_trunc('month', GREATEST($1::date, s.start_date)),
+  date_trunc('month', s.hi),
+  interval '1 month'
+ ) gs`,
+}
+
+// Summary: стоимость подписок в интервале [From,To] в режиме f.Mode — общий итог
+// и, если задан f.GroupBy, итоги по каждой комбинации измерений.
+// Каждое начисление пересчитывается в f.Currency по курсу, действовавшему на его дату.
+func (r *Repository) Summary(ctx context.Context, f SummaryFilter) (float64, []model.SummaryBucket, error) {
+	q := `
+WITH subs AS (
+ SELECT s.*, sv.category,
+  ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+   WHERE st.subscription_id = s.id ORDER BY t.name) AS tags,
+  LEAST(COALESCE(s.end_date, $2::date), $2::date) AS hi
+ FROM subscriptions s
+ LEFT JOIN services sv ON sv.id = s.service_id
+ WHERE s.deleted_at IS NULL
+   AND s.start_date <= $2::date
+   AND COALESCE(s.end_date, '9999-12-31') >= $1::date
+   %s
+), events AS (%s
+), conv AS (
+ SELECT e.*, rate_at(e.currency, e.d) AS src, rate_at($3, e.d) AS dst
+ FROM events e
+)
+SELECT %s
+ ROUND(COALESCE(%s, 0), 2)::float8 AS total,
+ MIN(to_char(d, 'YYYY-MM-DD') || ' ' || CASE WHEN src IS NULL THEN currency ELSE $3 END)
+  FILTER (WHERE src IS NULL OR dst IS NULL) AS missing
+FROM conv%s
+%s;
+`
+	if f.Currency == "" {
+		f.Currency = model.BaseCurrency
+	}
+	if f.Mode == "" {
+		f.Mode = SummaryCharges
+	}
+	events, ok := summaryEvents[f.Mode]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown summary mode %q", f.Mode)
+	}
+	// filters
+	filter := ""
+	args := []any{f.From, f.To, f.Currency}
+	idx := 4
+
+	if f.UserID != nil {
+		filter += " AND s.user_id=$" + itoa(idx)
+		args = append(args, *f.UserID)
+		idx++
+	}
+	if f.ServiceName != nil {
+		cond, v := serviceNameCond("s.service_name", f.ServiceMatch, []string{*f.ServiceName})
+		filter += " AND " + strings.ReplaceAll(cond, "?", "$"+itoa(idx))
+		args = append(args, v)
+		idx++
+	}
+	if f.Category != nil {
+		filter += " AND lower(sv.category) = lower($" + itoa(idx) + ")"
+		args = append(args, *f.Category)
+		idx++
+	}
+	if len(f.Tags) > 0 {
+		filter += " AND s.id IN (SELECT st.subscription_id FROM subscription_tags st JOIN tags t ON t.id = st.tag_id" +
+			" WHERE t.name = ANY($" + itoa(idx) + "::text[]))"
+		args = append(args, f.Tags)
+		idx++
+	}
+
+	// group by: строка с grp = 0 — бакет, остальная (GROUPING SETS ... ()) — общий итог.
+	// По тегам начисление размножается на каждый тег подписки; в общий итог идёт только первая копия (ord = 1).
+	selectDims, sum, tagJoin, groupBy := "0 AS grp,", "SUM(price * src / dst)", "", ""
+	if len(f.GroupBy) > 0 {
+		exprs := make([]string, 0, len(f.GroupBy))
+		for _, g := range f.GroupBy {
+			e, ok := summaryGroups[g]
+			if !ok {
+				return 0, nil, fmt.Errorf("unknown summary group %q", g)
+			}
+			exprs = append(exprs, e)
+			if g == GroupTag {
+				tagJoin = "\nCROSS JOIN LATERAL unnest(CASE WHEN cardinality(tags) = 0 THEN ARRAY['untagged'] ELSE tags END)" +
+					" WITH ORDINALITY AS tg(tag, ord)"
+			}
+		}
+		dims := strings.Join(exprs, ", ")
+		selectDims = dims + ", GROUPING(" + dims + ") AS grp,"
+		if tagJoin != "" {
+			sum = "CASE WHEN GROUPING(" + dims + ") = 0 THEN SUM(price * src / dst)" +
+				" ELSE SUM(price * src / dst) FILTER (WHERE tg.ord = 1) END"
+		}
+		groupBy = "GROUP BY GROUPING SETS ((" + dims + "), ()) ORDER BY " + dims
+	}
+
+	query := sprintf(q, filter, events, selectDims, sum, tagJoin, groupBy)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		total   float64
+		buckets = []model.SummaryBucket{}
+	)
+	for rows.Next() {
+		var (
+			b        model.SummaryBucket
+			grouping int
+			missing  *string
+		)
+		dest := make([]any, 0, len(f.GroupBy)+3)
+		for _, g := range f.GroupBy {
+			switch g {
+			case GroupMonth:
+				dest = append(dest, &b.Month)
+			case GroupServiceName:
+				dest = append(dest, &b.ServiceName)
+			case GroupUserID:
+				dest = append(dest, &b.UserID)
+			case GroupCategory:
+				dest = append(dest, &b.Category)
+			case GroupTag:
+				dest = append(dest, &b.Tag)
+			}
+		}
+		dest = append(dest, &grouping, &b.Total, &missing)
+		if err := rows.Scan(dest...); err != nil {
+			return 0, nil, err
+		}
+		if missing != nil {
+			return 0, nil, fmt.Errorf("%w: %s", ErrNoRate, *missing)
+		}
+		if len(f.GroupBy) == 0 || grouping != 0 {
+			total = b.Total
+			continue
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	return total, buckets, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// addTags: создаёт недостающие теги и привязывает их к подписке; имена уже нормализованы.
+// Возвращает число новых привязок — уже привязанные теги не считаются.
+func (r *Repository) addTags(ctx context.Context, id uuid.UUID, names []string) (int64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	if _, err := r.db.Exec(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, names); err != nil {
+		return 0, err
+	}
+	ct, err := r.db.Exec(ctx, `
+		INSERT INTO subscription_tags (subscription_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2::text[])
+		ON CONFLICT DO NOTHING`, id, names)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// AddTags: добавляет теги подписке и возвращает все её теги; false — подписки нет
+func (r *Repository) AddTags(ctx context.Context, id uuid.UUID, names []string) ([]string, bool, error) {
+	var (
+		tags []string
+		ok   bool
+	)
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lock(ctx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		ok = true
+		added, err := tx.addTags(ctx, id, names)
+		if err != nil {
+			return err
+		}
+		if err := tx.db.QueryRow(ctx, `
+			SELECT ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.subscription_id = $1 ORDER BY t.name)`, id).Scan(&tags); err != nil {
+			return err
+		}
+		// все теги уже были у подписки — версия и лента изменений не трогаются
+		if added == 0 {
+			return nil
+		}
+		if err := tx.touch(ctx, id); err != nil {
+			return err
+		}
+		return tx.changed(ctx, model.EventSubscriptionUpdated, id, before)
+	})
+	return tags, ok, err
+}
+
+// RemoveTag: false — у подписки нет такого тега (или нет самой подписки)
+func (r *Repository) RemoveTag(ctx context.Context, id uuid.UUID, name string) (bool, error) {
+	var ok bool
+	err := r.inTx(ctx, func(tx *Repository) error {
+		before, err := tx.lock(ctx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		ct, err := tx.db.Exec(ctx, `
+			DELETE FROM subscription_tags
+			WHERE subscription_id=$1 AND tag_id = (SELECT id FROM tags WHERE name=$2)`, id, name)
+		if err != nil {
+			return err
+		}
+		if ok = ct.RowsAffected() == 1; !ok {
+			return nil
+		}
+		if err := tx.touch(ctx, id); err != nil {
+			return err
+		}
+		return tx.changed(ctx, model.EventSubscriptionUpdated, id, before)
+	})
+	return ok, err
+}
+
+// ListTags: используемые теги, популярные сначала
+func (r *Repository) ListTags(ctx context.Context) ([]model.TagUsage, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT t.name, COUNT(*) FROM tags t
+		JOIN subscription_tags st ON st.tag_id = t.id
+		JOIN subscriptions s ON s.id = st.subscription_id AND s.deleted_at IS NULL
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.TagUsage{}
+	for rows.Next() {
+		var t model.TagUsage
+		if err := rows.Scan(&t.Name, &t.Subscriptions); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+// touch обновляет updated_at и версию, чтобы изменение тегов попало в ленту изменений подписок и сменило ETag
+func (r *Repository) touch(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `UPDATE subscriptions SET updated_at=now(), version=version+1 WHERE id=$1`, id)
+	return err
+}
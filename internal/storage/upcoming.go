@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+type UpcomingFilter struct {
+	From     time.Time // первый день окна
+	To       time.Time // последний день окна (включительно)
+	UserID   *uuid.UUID
+	Currency string // валюта Amount, по умолчанию RUB
+}
+
+// Upcoming: списания активных подписок с датой в [From,To] в хронологическом порядке.
+// Даты считаются от start_date с шагом периода (charge_dates), цена — действующая на дату списания.
+func (r *Repository) Upcoming(ctx context.Context, f UpcomingFilter) ([]model.UpcomingCharge, error) {
+	q := `
+WITH subs AS (
+ SELECT s.*, LEAST(COALESCE(s.end_date, $2::date), $2::date) AS hi
+ FROM subscriptions s
+ WHERE s.deleted_at IS NULL
+   AND s.start_date <= $2::date
+   AND COALESCE(s.end_date, '9999-12-31') >= $1::date
+   %s
+), conv AS (
+ SELECT s.id, s.service_name, s.user_id, d, COALESCE(price_at(s.id, d), s.price) AS price, s.currency,
+  rate_at(s.currency, d) AS src, rate_at($3, d) AS dst
+ FROM subs s
+ CROSS JOIN LATERAL charge_dates(s.start_date, s.billing_period, s.billing_interval, $1::date, s.hi) d
+)
+SELECT id, service_name, user_id, d, price, currency, ROUND(price * src / dst, 2)::float8
+FROM conv
+ORDER BY d, service_name, id;
+`
+	if f.Currency == "" {
+		f.Currency = model.BaseCurrency
+	}
+	filter := ""
+	args := []any{f.From, f.To, f.Currency}
+	if f.UserID != nil {
+		filter += " AND s.user_id=$4"
+		args = append(args, *f.UserID)
+	}
+
+	rows, err := r.db.Query(ctx, sprintf(q, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.UpcomingCharge{}
+	for rows.Next() {
+		var (
+			c      model.UpcomingCharge
+			amount *float64
+		)
+		if err := rows.Scan(&c.SubscriptionID, &c.ServiceName, &c.UserID, &c.Date, &c.Price, &c.Currency, &amount); err != nil {
+			return nil, err
+		}
+		if amount == nil {
+			return nil, fmt.Errorf("%w: %s %s or %s", ErrNoRate, c.Date.Format("2006-01-02"), c.Currency, f.Currency)
+		}
+		c.Amount = *amount
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
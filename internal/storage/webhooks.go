@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookColumns = `id, url, events, active, created_at, updated_at`
+
+func scanWebhook(row pgx.Row, w *model.Webhook) error {
+	return row.Scan(&w.ID, &w.URL, &w.Events, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (r *Repository) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO webhooks (url, secret, events, active) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`,
+		w.URL, w.Secret, w.Events, w.Active).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	var w model.Webhook
+	err := scanWebhook(r.db.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id), &w)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Webhook{}
+	for rows.Next() {
+		var w model.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, rows.Err()
+}
+
+// UpdateWebhook: пустой w.Secret — секрет не меняется
+func (r *Repository) UpdateWebhook(ctx context.Context, id uuid.UUID, w *model.Webhook) (bool, error) {
+	err := r.db.QueryRow(ctx, `
+		UPDATE webhooks
+		SET url=$1, secret=COALESCE(NULLIF($2, ''), secret), events=$3, active=$4, updated_at=now()
+		WHERE id=$5
+		RETURNING id, created_at, updated_at`,
+		w.URL, w.Secret, w.Events, w.Active, id).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteWebhook: журнал доставок удаляется вместе с вебхуком
+func (r *Repository) DeleteWebhook(ctx context.Context, id uuid.UUID) (bool, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM webhooks WHERE id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// ListDeliveries: журнал доставок вебхука, новые сначала; status — опциональный фильтр
+func (r *Repository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]model.WebhookDelivery, error) {
+	q := `
+		SELECT d.id, d.webhook_id, d.event_id, o.event, d.status, d.attempts, d.next_attempt_at,
+		       d.last_status_code, d.last_error, d.created_at, d.updated_at
+		FROM webhook_deliveries d
+		JOIN outbox o ON o.id = d.event_id
+		WHERE d.webhook_id=$1`
+	args := []any{webhookID}
+	if status != "" {
+		q += " AND d.status=$2"
+		args = append(args, status)
+	}
+	q += " ORDER BY d.id DESC LIMIT " + itoa(limit)
+
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.WebhookDelivery{}
+	for rows.Next() {
+		var d model.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+// RetryDelivery возвращает доставку в очередь с новым счётчиком попыток; false — нет такой доставки
+func (r *Repository) RetryDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (bool, error) {
+	ct, err := r.db.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status='pending', attempts=0, next_attempt_at=now(), updated_at=now()
+		WHERE id=$1 AND webhook_id=$2`, id, webhookID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
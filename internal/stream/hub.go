@@ -0,0 +1,179 @@
+// Package stream раздаёт изменения подписок подключённым SSE-клиентам.
+// Источник — таблица subscription_changes и NOTIFY subscription_changes, поэтому клиенты
+// любого инстанса видят изменения, сделанные через все инстансы.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/model"
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	channel      = "subscription_changes"
+	pollInterval = 5 * time.Second // страховка от потерянных NOTIFY и для изменений, которые ждали завершения более старой транзакции
+	batch        = 500
+	subBuffer    = 256
+
+	// Retention: сколько хранится лента изменений, т.е. насколько давний Last-Event-ID можно продолжить
+	Retention = 24 * time.Hour
+	// KindPurge: задача очереди, очищающая ленту
+	KindPurge = "stream.purge"
+)
+
+// Sub: подписка клиента. C закрывается, если клиент не успевает читать, — он переподключится с Last-Event-ID.
+type Sub struct {
+	C      chan model.SubscriptionChange
+	userID *uuid.UUID
+}
+
+type Hub struct {
+	pool *pgxpool.Pool
+	repo *storage.Repository
+	log  *slog.Logger
+
+	mu   sync.Mutex
+	subs map[*Sub]struct{}
+
+	pollMu sync.Mutex
+	last   storage.ChangePos // последнее разосланное изменение
+}
+
+func NewHub(pool *pgxpool.Pool, repo *storage.Repository, lg *slog.Logger) *Hub {
+	return &Hub{pool: pool, repo: repo, log: lg, subs: map[*Sub]struct{}{}}
+}
+
+// Subscribe: новые изменения (все или одного пользователя)
+func (h *Hub) Subscribe(userID *uuid.UUID) *Sub {
+	s := &Sub{C: make(chan model.SubscriptionChange, subBuffer), userID: userID}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Sub) {
+	h.mu.Lock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.C)
+	}
+	h.mu.Unlock()
+}
+
+// Run слушает NOTIFY и раз в pollInterval проверяет ленту сам; работает до отмены ctx,
+// после чего закрывает все подписки, чтобы открытые потоки завершились
+func (h *Hub) Run(ctx context.Context) {
+	last, err := h.repo.ChangeHorizon(ctx)
+	if err != nil {
+		h.log.Error("stream init", slog.Any("err", err))
+	}
+	h.last = last
+
+	go h.listen(ctx)
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-t.C:
+		}
+		h.poll(ctx)
+	}
+}
+
+// Purge — обработчик KindPurge: удаляет ленту старше Retention; запускается по расписанию очереди
+func (h *Hub) Purge(ctx context.Context, _ json.RawMessage) error {
+	_, err := h.repo.PurgeChanges(ctx, time.Now().Add(-Retention))
+	return err
+}
+
+// listen держит отдельное соединение с LISTEN и переподключается при ошибках
+func (h *Hub) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := h.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			h.log.Warn("stream listen", slog.Any("err", err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (h *Hub) listenOnce(ctx context.Context) error {
+	conn, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// соединение после LISTEN в пул не возвращаем
+	defer conn.Hijack().Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	h.poll(ctx) // то, что пришло, пока не слушали
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+		h.poll(ctx)
+	}
+}
+
+// poll дочитывает ленту после h.last и рассылает подписчикам
+func (h *Hub) poll(ctx context.Context) {
+	h.pollMu.Lock()
+	defer h.pollMu.Unlock()
+	for {
+		items, err := h.repo.ChangesSince(ctx, h.last, nil, batch)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.log.Warn("stream poll", slog.Any("err", err))
+			}
+			return
+		}
+		for _, c := range items {
+			h.broadcast(c)
+			h.last = storage.PosOf(c)
+		}
+		if len(items) < batch {
+			return
+		}
+	}
+}
+
+func (h *Hub) broadcast(c model.SubscriptionChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.userID != nil && *s.userID != c.UserID {
+			continue
+		}
+		select {
+		case s.C <- c:
+		default:
+			delete(h.subs, s)
+			close(s.C)
+		}
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.C)
+	}
+}
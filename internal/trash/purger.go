@@ -0,0 +1,36 @@
+// Package trash окончательно удаляет подписки, пролежавшие в корзине дольше срока хранения.
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+)
+
+// KindPurge: задача очереди, очищающая корзину
+const KindPurge = "trash.purge"
+
+type Purger struct {
+	Repo      *storage.Repository
+	Log       *slog.Logger
+	Retention time.Duration // сколько подписка лежит в корзине
+}
+
+func New(repo *storage.Repository, lg *slog.Logger, retention time.Duration) *Purger {
+	return &Purger{Repo: repo, Log: lg, Retention: retention}
+}
+
+// Purge — обработчик KindPurge; запускается по расписанию очереди. DELETE идемпотентен, повтор безопасен.
+func (p *Purger) Purge(ctx context.Context, _ json.RawMessage) error {
+	n, err := p.Repo.PurgeTrash(ctx, time.Now().Add(-p.Retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		p.Log.Info("trash_purged", slog.Int64("count", n))
+	}
+	return nil
+}
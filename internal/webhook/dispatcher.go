@@ -0,0 +1,184 @@
+// Package webhook доставляет события из outbox на зарегистрированные вебхуки.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AlexeiDevelop/subscriptions-api/internal/storage"
+)
+
+// KindEndingSoon: задача очереди, создающая события subscription.ending_soon
+const KindEndingSoon = "webhook.ending_soon"
+
+const (
+	batchSize  = 50
+	workers    = 8
+	maxBackoff = 6 * time.Hour
+)
+
+// Dispatcher периодически раскладывает outbox по вебхукам и отправляет доставки с ретраями.
+// Несколько инстансов не мешают друг другу: события и доставки забираются через SKIP LOCKED.
+type Dispatcher struct {
+	Repo           *storage.Repository
+	Log            *slog.Logger
+	Client         *http.Client
+	Interval       time.Duration // как часто проверять outbox и очередь доставок
+	MaxAttempts    int           // после стольких неудач доставка переходит в dead
+	BaseBackoff    time.Duration // задержка после первой неудачи, дальше удваивается
+	EndingSoonDays int           // за сколько дней до end_date слать ending_soon
+}
+
+func New(repo *storage.Repository, lg *slog.Logger, timeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		Repo:           repo,
+		Log:            lg,
+		Client:         publicClient(timeout),
+		Interval:       5 * time.Second,
+		MaxAttempts:    8,
+		BaseBackoff:    30 * time.Second,
+		EndingSoonDays: 7,
+	}
+}
+
+// Run работает до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.Interval)
+	defer t.Stop()
+	for {
+		d.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) {
+	for {
+		n, err := d.Repo.DispatchOutbox(ctx, batchSize)
+		if err != nil {
+			d.Log.Warn("webhook dispatch", slog.Any("err", err))
+			break
+		}
+		if n < batchSize {
+			break
+		}
+	}
+
+	// lease с запасом на таймаут клиента: пока доставка в работе, другие инстансы её не возьмут
+	items, err := d.Repo.ClaimDeliveries(ctx, batchSize, d.Client.Timeout+time.Minute)
+	if err != nil {
+		d.Log.Warn("webhook claim", slog.Any("err", err))
+		return
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for _, it := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(it storage.PendingDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			d.deliver(ctx, it)
+		}(it)
+	}
+	wg.Wait()
+}
+
+// EndingSoon — обработчик KindEndingSoon; запускается по расписанию очереди.
+// Повтор безопасен: для одной подписки и end_date событие создаётся один раз.
+func (d *Dispatcher) EndingSoon(ctx context.Context, _ json.RawMessage) error {
+	n, err := d.Repo.EmitEndingSoon(ctx, d.EndingSoonDays)
+	if err == nil && n > 0 {
+		d.Log.Info("webhook ending soon", slog.Int64("events", n))
+	}
+	return err
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, it storage.PendingDelivery) {
+	code, err := d.send(ctx, it)
+	ok := err == nil && code >= 200 && code < 300
+	var (
+		codePtr *int
+		errText *string
+		next    *time.Time
+	)
+	if code != 0 {
+		codePtr = &code
+	}
+	if !ok {
+		msg := fmt.Sprintf("unexpected status %d", code)
+		if err != nil {
+			msg = err.Error()
+		}
+		errText = &msg
+		if it.Attempts+1 < d.MaxAttempts {
+			t := time.Now().Add(Backoff(d.BaseBackoff, it.Attempts+1))
+			next = &t
+		}
+		d.Log.Warn("webhook delivery failed",
+			slog.Int64("delivery_id", it.ID),
+			slog.Int("attempt", it.Attempts+1),
+			slog.String("err", msg))
+	}
+	if err := d.Repo.FinishDelivery(ctx, it.ID, ok, codePtr, errText, next); err != nil {
+		d.Log.Error("webhook finish", slog.Int64("delivery_id", it.ID), slog.Any("err", err))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, it storage.PendingDelivery) (int, error) {
+	body, err := json.Marshal(it.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, it.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "subscriptions-api-webhooks")
+	req.Header.Set("X-Webhook-Event", it.Event.Type)
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(it.Event.ID, 10))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(it.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(it.Secret, ts, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign: hex HMAC-SHA256 от "<timestamp>.<body>" — получатель проверяет подпись и свежесть timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Backoff: base * 2^(attempt-1), но не больше maxBackoff
+func Backoff(base time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget: вебхук ведёт во внутреннюю сеть (loopback, link-local, частные адреса)
+var ErrPrivateTarget = errors.New("webhook target is not a public address")
+
+// reserved: диапазоны, которые не отсекают методы netip.Addr
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // бенчмарки
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// publicAddr: адрес из публичного интернета
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckTarget: host вебхука (имя или IP) должен вести только на публичные адреса.
+// Имя, которое пока не резолвится, пропускается — адрес всё равно проверяется при каждой отправке.
+func CheckTarget(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		if !publicAddr(ip) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range addrs {
+		if !publicAddr(ip) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// publicClient: HTTP-клиент, который соединяется только с публичными адресами. Проверка — в момент
+// соединения, поэтому имя, сменившее адрес после регистрации вебхука (DNS rebinding), тоже не пройдёт.
+// Прокси из окружения не используется: иначе проверялся бы адрес прокси, а не вебхука.
+func publicClient(timeout time.Duration) *http.Client {
+	d := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(ap.Addr()) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = nil
+	tr.DialContext = d.DialContext
+	return &http.Client{Timeout: timeout, Transport: tr}
+}
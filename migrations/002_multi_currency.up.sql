@@ -0,0 +1,20 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency TEXT NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    rate NUMERIC(18, 6) NOT NULL CHECK (rate > 0),
+    valid_from DATE NOT NULL,
+    PRIMARY KEY (currency, valid_from)
+);
+
+-- Курс валюты к RUB, действующий на дату d (NULL, если курса нет)
+CREATE OR REPLACE FUNCTION rate_at(cur TEXT, d DATE) RETURNS NUMERIC
+LANGUAGE sql STABLE AS $$
+    SELECT CASE WHEN cur = 'RUB' THEN 1::numeric ELSE (
+        SELECT r.rate FROM exchange_rates r
+        WHERE r.currency = cur AND r.valid_from <= d
+        ORDER BY r.valid_from DESC
+        LIMIT 1
+    ) END
+$$;
@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS charge_dates(DATE, TEXT, INTEGER, DATE, DATE);
+DROP FUNCTION IF EXISTS billing_months(TEXT, INTEGER);
+DROP FUNCTION IF EXISTS billing_step(TEXT, INTEGER);
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_interval,
+    DROP COLUMN IF EXISTS billing_period;
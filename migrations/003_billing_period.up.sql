@@ -0,0 +1,41 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period TEXT NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1 CHECK (billing_interval >= 1);
+
+-- Шаг между списаниями: каждые n недель/месяцев/кварталов/лет
+CREATE OR REPLACE FUNCTION billing_step(period TEXT, n INTEGER) RETURNS INTERVAL
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE period
+        WHEN 'weekly' THEN make_interval(weeks => n)
+        WHEN 'monthly' THEN make_interval(months => n)
+        WHEN 'quarterly' THEN make_interval(months => 3 * n)
+        WHEN 'yearly' THEN make_interval(years => n)
+    END
+$$;
+
+-- Сколько месяцев покрывает одно списание (для амортизации по месяцам)
+CREATE OR REPLACE FUNCTION billing_months(period TEXT, n INTEGER) RETURNS NUMERIC
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT CASE period
+        WHEN 'weekly' THEN n * 12 / 52.0
+        WHEN 'monthly' THEN n::numeric
+        WHEN 'quarterly' THEN 3 * n::numeric
+        WHEN 'yearly' THEN 12 * n::numeric
+    END
+$$;
+
+-- Даты списаний start + k*step, попавшие в [lo, hi].
+-- k считается умножением, а не накоплением, чтобы 31-е число не «съезжало» после февраля.
+CREATE OR REPLACE FUNCTION charge_dates(start DATE, period TEXT, n INTEGER, lo DATE, hi DATE) RETURNS SETOF DATE
+LANGUAGE sql IMMUTABLE AS $$
+    WITH b AS (
+        SELECT CASE WHEN period = 'weekly' THEN 7 * n ELSE 28 * billing_months(period, n)::int END AS min_days,
+               CASE WHEN period = 'weekly' THEN 7 * n ELSE 31 * billing_months(period, n)::int END AS max_days
+    )
+    SELECT d FROM (
+        SELECT (start + k * billing_step(period, n))::date AS d
+        FROM b, generate_series(GREATEST(0, (lo - start) / b.max_days), GREATEST(-1, (hi - start) / b.min_days + 1)) k
+    ) t
+    WHERE d BETWEEN lo AND hi
+$$;
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS price_at(UUID, DATE);
+DROP TABLE IF EXISTS subscription_prices;
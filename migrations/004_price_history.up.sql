@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    valid_from DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, valid_from)
+);
+
+-- Текущая цена каждой подписки становится первой записью истории
+INSERT INTO subscription_prices (subscription_id, price, valid_from)
+SELECT id, price, start_date FROM subscriptions
+ON CONFLICT DO NOTHING;
+
+-- Цена подписки, действующая на дату d; если d раньше всей истории — самая ранняя цена
+CREATE OR REPLACE FUNCTION price_at(sub UUID, d DATE) RETURNS INTEGER
+LANGUAGE sql STABLE AS $$
+    SELECT p.price FROM subscription_prices p
+    WHERE p.subscription_id = sub
+    ORDER BY p.valid_from <= d DESC,
+             CASE WHEN p.valid_from <= d THEN p.valid_from END DESC NULLS LAST,
+             p.valid_from
+    LIMIT 1
+$$;
@@ -0,0 +1,5 @@
+-- end_date теперь последний день подписки (включительно).
+-- Раньше хранилось 1-е число месяца окончания, а месяц считался целиком — переносим на последний день месяца.
+UPDATE subscriptions
+SET end_date = (date_trunc('month', end_date) + interval '1 month - 1 day')::date
+WHERE end_date IS NOT NULL;
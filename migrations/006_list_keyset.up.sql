@@ -0,0 +1,2 @@
+-- Порядок списка и keyset-пагинация: (created_at, id) DESC
+CREATE INDEX IF NOT EXISTS idx_subscriptions_created_id ON subscriptions (created_at DESC, id DESC);
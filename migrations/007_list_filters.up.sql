@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_subscriptions_price ON subscriptions (price);
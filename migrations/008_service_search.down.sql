@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_service_trgm;
+DROP INDEX IF EXISTS idx_subscriptions_service_lower;
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- lower(service_name): сравнение без учёта регистра и поиск по префиксу
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_lower ON subscriptions (lower(service_name) text_pattern_ops);
+-- нечёткий поиск (оператор %, similarity)
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_trgm ON subscriptions USING gin (service_name gin_trgm_ops);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category TEXT,
+    default_price INTEGER CHECK (default_price >= 0),
+    currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    homepage TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services (lower(name));
+CREATE INDEX IF NOT EXISTS idx_services_category ON services (lower(category));
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions (service_id);
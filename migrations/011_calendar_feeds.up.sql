@@ -0,0 +1,6 @@
+-- Секретные ссылки на iCalendar-ленты пользователей; хранится только sha256 токена
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS budget_breaches;
+DROP TABLE IF EXISTS budgets;
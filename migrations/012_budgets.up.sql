@@ -0,0 +1,31 @@
+-- Месячные бюджеты пользователей: общий, на сервис или на категорию каталога
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    scope TEXT NOT NULL CHECK (scope IN ('total', 'service', 'category')),
+    target TEXT,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    mode TEXT NOT NULL DEFAULT 'charges' CHECK (mode IN ('charges', 'amortized')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((scope = 'total') = (target IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_scope ON budgets (user_id, scope, lower(COALESCE(target, '')));
+
+-- Превышения бюджетов: не больше одного на бюджет в месяц
+CREATE TABLE IF NOT EXISTS budget_breaches (
+    id BIGSERIAL PRIMARY KEY,
+    budget_id UUID NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    month DATE NOT NULL,
+    spent NUMERIC(14, 2) NOT NULL,
+    amount INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    subscription_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (budget_id, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_breaches_user ON budget_breaches (user_id, created_at DESC);
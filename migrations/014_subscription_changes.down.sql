@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS subscriptions_notify ON subscriptions;
+DROP FUNCTION IF EXISTS notify_subscription_change();
+DROP TABLE IF EXISTS subscription_changes;
@@ -0,0 +1,38 @@
+-- Лента изменений подписок для SSE: строка на каждое изменение и NOTIFY с её id.
+-- id служит Last-Event-ID, поэтому клиент может продолжить с места обрыва.
+CREATE TABLE IF NOT EXISTS subscription_changes (
+    id BIGSERIAL PRIMARY KEY,
+    op TEXT NOT NULL CHECK (op IN ('created', 'updated', 'deleted')),
+    subscription_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_changes_user ON subscription_changes (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_subscription_changes_created ON subscription_changes (created_at);
+
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    rec subscriptions;
+    change_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+    INSERT INTO subscription_changes (op, subscription_id, user_id, data)
+    VALUES (CASE TG_OP WHEN 'INSERT' THEN 'created' WHEN 'UPDATE' THEN 'updated' ELSE 'deleted' END,
+            rec.id, rec.user_id, to_jsonb(rec))
+    RETURNING id INTO change_id;
+    PERFORM pg_notify('subscription_changes', change_id::text);
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS subscriptions_notify ON subscriptions;
+CREATE TRIGGER subscriptions_notify
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change();
@@ -0,0 +1,18 @@
+-- Журнал изменений подписок: состояние до и после, кто и в каком запросе изменил.
+-- Без внешнего ключа: записи об удалённых подписках остаются.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    before JSONB,
+    after JSONB,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_subscription ON audit_log (subscription_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log (created_at);
@@ -0,0 +1,30 @@
+-- Подписки из корзины при откате удаляются окончательно
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    rec subscriptions;
+    change_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+    INSERT INTO subscription_changes (op, subscription_id, user_id, data)
+    VALUES (CASE TG_OP WHEN 'INSERT' THEN 'created' WHEN 'UPDATE' THEN 'updated' ELSE 'deleted' END,
+            rec.id, rec.user_id, to_jsonb(rec))
+    RETURNING id INTO change_id;
+    PERFORM pg_notify('subscription_changes', change_id::text);
+    RETURN NULL;
+END
+$$;
+
+DELETE FROM audit_log WHERE action = 'restored';
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('created', 'updated', 'deleted'));
+
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,46 @@
+-- Мягкое удаление: удалённая подписка остаётся в корзине до restore или очистки по сроку хранения.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('created', 'updated', 'deleted', 'restored'));
+
+-- Для ленты изменений перенос в корзину — deleted, восстановление — created.
+-- Изменения строк в корзине и их окончательное удаление в ленту не попадают.
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+    rec subscriptions;
+    change_op TEXT;
+    change_id BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        rec := NEW;
+        change_op := 'created';
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        rec := OLD;
+        change_op := 'deleted';
+    ELSE
+        rec := NEW;
+        IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NULL THEN
+            change_op := 'updated';
+        ELSIF OLD.deleted_at IS NULL THEN
+            change_op := 'deleted';
+        ELSIF NEW.deleted_at IS NULL THEN
+            change_op := 'created';
+        ELSE
+            RETURN NULL;
+        END IF;
+    END IF;
+    INSERT INTO subscription_changes (op, subscription_id, user_id, data)
+    VALUES (change_op, rec.id, rec.user_id, to_jsonb(rec))
+    RETURNING id INTO change_id;
+    PERFORM pg_notify('subscription_changes', change_id::text);
+    RETURN NULL;
+END
+$$;
@@ -0,0 +1,2 @@
+-- Версия подписки для оптимистичной блокировки (ETag / If-Match): растёт при каждом изменении.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
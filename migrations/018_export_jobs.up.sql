@@ -0,0 +1,22 @@
+-- Фоновые выгрузки и отчёты: задача ждёт в очереди, результат — файл в локальном хранилище до expires_at
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind TEXT NOT NULL CHECK (kind IN ('export', 'report')),
+    format TEXT NOT NULL,
+    query TEXT NOT NULL DEFAULT '', -- параметры в виде query-строки, как у GET /subscriptions/export или /summary
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed', 'expired')),
+    processed BIGINT NOT NULL DEFAULT 0,
+    total BIGINT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ, -- аренда running-задачи: истекла — инстанс упал, задачу забирает другой
+    error TEXT,
+    file_name TEXT,
+    size BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_pending ON export_jobs (created_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires ON export_jobs (expires_at) WHERE status = 'done';
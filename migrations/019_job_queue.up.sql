@@ -0,0 +1,29 @@
+-- Очередь фоновых задач: воркеры всех инстансов забирают задачи через SKIP LOCKED
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- не раньше; после ошибки сдвигается на backoff
+    locked_until TIMESTAMPTZ,                  -- аренда running-задачи: истекла — инстанс упал, задачу забирают заново
+    last_error TEXT,
+    dedup_key TEXT UNIQUE,                     -- запуск по расписанию: schedule:<kind>:<время>
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_jobs_finished ON jobs (finished_at) WHERE status IN ('done', 'dead');
+
+-- Расписания: задачи ставит в очередь только лидер (advisory lock), следующий запуск переживает смену лидера
+CREATE TABLE IF NOT EXISTS job_schedules (
+    kind TEXT PRIMARY KEY,
+    spec TEXT NOT NULL, -- cron из 5 полей, @hourly/@daily/... или @every <duration>
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
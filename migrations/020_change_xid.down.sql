@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscription_changes_xid;
+ALTER TABLE subscription_changes DROP COLUMN IF EXISTS xid;
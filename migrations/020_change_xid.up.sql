@@ -0,0 +1,6 @@
+-- id изменения выдаётся при INSERT, а виден после COMMIT, поэтому порядок id не совпадает с порядком фиксации.
+-- Лента читается по (xid, id) и только до горизонта pg_snapshot_xmin: транзакции с меньшим xid уже завершены,
+-- и новых строк с такой позицией не появится.
+ALTER TABLE subscription_changes ADD COLUMN IF NOT EXISTS xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_subscription_changes_xid ON subscription_changes (xid, id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS services_names ON services;
+DROP FUNCTION IF EXISTS sync_service_names();
+DROP TABLE IF EXISTS service_names;
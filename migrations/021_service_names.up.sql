@@ -0,0 +1,30 @@
+-- Названия и алиасы каталога без учёта регистра: уникальность держит база, а не только проверка в приложении,
+-- поэтому два параллельных запроса не займут один алиас. Таблица ведётся триггером на services.
+CREATE TABLE IF NOT EXISTS service_names (
+    lower_name TEXT PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_names_service ON service_names (service_id);
+
+CREATE OR REPLACE FUNCTION sync_service_names() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM service_names WHERE service_id = NEW.id;
+    INSERT INTO service_names (lower_name, service_id)
+    SELECT DISTINCT lower(n), NEW.id FROM unnest(array_prepend(NEW.name, NEW.aliases)) n;
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS services_names ON services;
+CREATE TRIGGER services_names
+    AFTER INSERT OR UPDATE OF name, aliases ON services
+    FOR EACH ROW EXECUTE FUNCTION sync_service_names();
+
+-- уже пересекающиеся записи не ломают миграцию: имя остаётся за первой, вторая упрётся в ограничение при изменении
+INSERT INTO service_names (lower_name, service_id)
+SELECT DISTINCT ON (lower(n)) lower(n), s.id
+FROM services s, unnest(array_prepend(s.name, s.aliases)) n
+ORDER BY lower(n), s.created_at
+ON CONFLICT DO NOTHING;